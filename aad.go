@@ -0,0 +1,104 @@
+package crypt
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+// aadKey derives the secretbox key binding a message to the associated data. The shared key is used as an HMAC key
+// over the associated data, so every distinct associated data results in an independent key.
+func (sk SharedKey) aadKey(aad []byte) [KeySize]byte {
+	mac := hmac.New(sha256.New, sk[:])
+	mac.Write(aad)
+
+	var key [KeySize]byte
+	copy(key[:], mac.Sum(nil))
+	return key
+}
+
+// EncryptWithAAD encrypts data using the private key intended for the peer public key, binding the result to the
+// associated data. The associated data is authenticated but neither encrypted nor included in the result, the
+// peer must supply the same associated data to DecryptWithAAD.
+//
+// The result is the sender's public key, the nonce and the secretbox sealed under a key derived from the shared key
+// and the associated data. Unlike Encrypt there is no header, and messages encrypted with Encrypt can't be decrypted
+// by DecryptWithAAD (and vice versa), even with empty associated data.
+func (key PrivateKey) EncryptWithAAD(peer PublicKey, data, aad []byte) []byte {
+	aadKey := key.Precompute(peer).aadKey(aad)
+
+	nonce := mustGenerateNonce()
+
+	result := make([]byte, 0, KeySize+len(nonce)+len(data)+secretbox.Overhead)
+	result = append(result, key[KeySize:]...)
+	result = append(result, nonce[:]...)
+	return secretbox.Seal(result, data, nonce.ptr(), &aadKey)
+}
+
+// DecryptWithAAD decrypts data that was encrypted via EncryptWithAAD. Decryption fails with ErrOpenFailed if the
+// associated data doesn't match.
+func (key PrivateKey) DecryptWithAAD(data, aad []byte) (PublicKey, []byte, error) {
+	if len(data) < KeySize {
+		return PublicKey{}, nil, decryptFailed(fmt.Errorf("invalid message: expected public key: %w", ErrShortMessage))
+	}
+
+	var pub PublicKey
+	copy(pub[:], data[:])
+	data = data[KeySize:]
+	if err := pub.Validate(); err != nil {
+		return pub, nil, decryptFailed(fmt.Errorf("invalid message: %w", err))
+	}
+
+	if len(data) < NonceSize {
+		return pub, nil, decryptFailed(fmt.Errorf("invalid message: expected nonce: %w", ErrMissingNonce))
+	}
+
+	var nonce [NonceSize]byte
+	copy(nonce[:], data[:])
+	data = data[NonceSize:]
+
+	aadKey := key.Precompute(pub).aadKey(aad)
+	opened, ok := secretbox.Open(nil, data, &nonce, &aadKey)
+	if !ok {
+		return pub, nil, decryptFailed(fmt.Errorf("invalid message: %w", ErrOpenFailed))
+	}
+
+	return pub, opened, nil
+}
+
+// SealWithAAD encrypts data using the shared key, binding the result to the associated data, and appends the
+// ciphertext to dst. Unlike EncryptWithAAD the caller supplies the nonce, which must be NonceSize bytes long and
+// must never be used twice with the same shared key. Neither the nonce nor the sender's public key are included in
+// the result. A ciphertext sealed via EncryptWithAAD is the part following the sender's public key and the nonce.
+func (sk SharedKey) SealWithAAD(dst, nonce, data, aad []byte) ([]byte, error) {
+	if len(nonce) != NonceSize {
+		return nil, fmt.Errorf("invalid nonce: got %d bytes, want %d", len(nonce), NonceSize)
+	}
+
+	var n [NonceSize]byte
+	copy(n[:], nonce)
+
+	aadKey := sk.aadKey(aad)
+	return secretbox.Seal(dst, data, &n, &aadKey), nil
+}
+
+// OpenWithAAD decrypts a ciphertext sealed via SealWithAAD, or via EncryptWithAAD, with the same nonce and
+// associated data and appends the plaintext to dst. Decryption fails with ErrOpenFailed if the associated data
+// doesn't match.
+func (sk SharedKey) OpenWithAAD(dst, nonce, ciphertext, aad []byte) ([]byte, error) {
+	if len(nonce) != NonceSize {
+		return nil, decryptFailed(fmt.Errorf("invalid message: expected nonce: %w", ErrMissingNonce))
+	}
+
+	var n [NonceSize]byte
+	copy(n[:], nonce)
+
+	aadKey := sk.aadKey(aad)
+	opened, ok := secretbox.Open(dst, ciphertext, &n, &aadKey)
+	if !ok {
+		return nil, decryptFailed(fmt.Errorf("invalid message: %w", ErrOpenFailed))
+	}
+	return opened, nil
+}
@@ -0,0 +1,88 @@
+package crypt
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncryptWithAAD(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	msg := []byte("Hello World")
+	aad := []byte("channel-1")
+
+	encrypted := k1.EncryptWithAAD(k2.PublicKey(), msg, aad)
+
+	pub, decrypted, err := k2.DecryptWithAAD(encrypted, aad)
+	if assert.NoError(t, err) {
+		assert.Equal(t, k1.PublicKey(), pub)
+		assert.Equal(t, msg, decrypted)
+	}
+
+	_, _, err = k2.DecryptWithAAD(encrypted, []byte("channel-2"))
+	assert.True(t, errors.Is(err, ErrOpenFailed))
+
+	_, _, err = k2.DecryptWithAAD(encrypted, nil)
+	assert.True(t, errors.Is(err, ErrOpenFailed))
+
+	_, _, err = k2.Decrypt(encrypted)
+	assert.True(t, errors.Is(err, ErrOpenFailed))
+
+	_, _, err = k2.DecryptWithAAD(k1.Encrypt(k2.PublicKey(), msg), nil)
+	assert.True(t, errors.Is(err, ErrOpenFailed))
+}
+
+func TestSealWithAAD(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	msg := []byte("Hello World")
+	aad := []byte("header")
+	nonce := counterNonce(1)
+
+	sk1 := k1.Precompute(k2.PublicKey())
+	sk2 := k2.Precompute(k1.PublicKey())
+
+	sealed, err := sk1.SealWithAAD(nil, nonce[:], msg, aad)
+	assert.NoError(t, err)
+	opened, err := sk2.OpenWithAAD(nil, nonce[:], sealed, aad)
+	if assert.NoError(t, err) {
+		assert.Equal(t, msg, opened)
+	}
+
+	_, err = sk2.OpenWithAAD(nil, nonce[:], sealed, []byte("other"))
+	assert.True(t, errors.Is(err, ErrOpenFailed))
+	other := counterNonce(2)
+	_, err = sk2.OpenWithAAD(nil, other[:], sealed, aad)
+	assert.True(t, errors.Is(err, ErrOpenFailed))
+	_, err = sk2.OpenWithAAD(nil, nonce[:NonceSize-1], sealed, aad)
+	assert.True(t, errors.Is(err, ErrMissingNonce))
+	_, err = sk1.SealWithAAD(nil, nonce[:NonceSize-1], msg, aad)
+	assert.EqualError(t, err, "invalid nonce: got 23 bytes, want 24")
+
+	t.Run("EncryptWithAAD", func(t *testing.T) {
+		// high level to low level
+		encrypted := k1.EncryptWithAAD(k2.PublicKey(), msg, aad)
+		opened, err := sk2.OpenWithAAD(nil, encrypted[KeySize:KeySize+NonceSize], encrypted[KeySize+NonceSize:], aad)
+		if assert.NoError(t, err) {
+			assert.Equal(t, msg, opened)
+		}
+
+		// low level to high level
+		encrypted = append(k1.PublicKey().Bytes(), nonce[:]...)
+		encrypted, err = sk1.SealWithAAD(encrypted, nonce[:], msg, aad)
+		assert.NoError(t, err)
+		pub, opened, err := k2.DecryptWithAAD(encrypted, aad)
+		if assert.NoError(t, err) {
+			assert.Equal(t, k1.PublicKey(), pub)
+			assert.Equal(t, msg, opened)
+		}
+	})
+}
@@ -0,0 +1,33 @@
+package crypt
+
+import (
+	"fmt"
+
+	"golang.org/x/crypto/nacl/box"
+)
+
+// SealAnonymous encrypts data for the peer public key without a sender key. An ephemeral key pair is generated for
+// every message, so the output is compatible with libsodium's crypto_box_seal.
+func SealAnonymous(peer PublicKey, data []byte) ([]byte, error) {
+	var pub [KeySize]byte
+	copy(pub[:], peer[:])
+
+	return box.SealAnonymous(nil, data, &pub, randReader)
+}
+
+// OpenAnonymous decrypts data that was encrypted via SealAnonymous. Unlike Decrypt no peer public key is returned
+// because sealed boxes are anonymous: the ephemeral sender key identifies nobody.
+func (key PrivateKey) OpenAnonymous(data []byte) ([]byte, error) {
+	var priv [KeySize]byte
+	copy(priv[:], key[:KeySize])
+
+	var pub [KeySize]byte
+	copy(pub[:], key[KeySize:])
+
+	opened, ok := box.OpenAnonymous(nil, data, &pub, &priv)
+	if !ok {
+		return nil, decryptFailed(fmt.Errorf("invalid message: %w", ErrOpenFailed))
+	}
+
+	return opened, nil
+}
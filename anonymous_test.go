@@ -0,0 +1,45 @@
+package crypt
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/mr-tron/base58"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnonymous(t *testing.T) {
+	k, err := Generate()
+	assert.NoError(t, err)
+
+	msg := []byte("Hello World")
+
+	sealed, err := SealAnonymous(k.PublicKey(), msg)
+	assert.NoError(t, err)
+
+	opened, err := k.OpenAnonymous(sealed)
+	if assert.NoError(t, err) {
+		assert.Equal(t, msg, opened)
+	}
+
+	other, err := Generate()
+	assert.NoError(t, err)
+	_, err = other.OpenAnonymous(sealed)
+	assert.Error(t, err)
+}
+
+func TestAnonymousLibsodium(t *testing.T) {
+	// generated with libsodium's crypto_box_seal
+	priv, _ := hex.DecodeString("0102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f20")
+	sealed, _ := hex.DecodeString("6656e7e4ac4b427e7bef4b53809a97d2f30f90ab296fb46cdde63b5e50adf66d" +
+		"215ccf89f5c702e31670d888ee92d60667697b8ddc20b913dfe1de057f5d0dac6b35befe")
+
+	k, err := NewPrivateKey(base58.Encode(priv))
+	assert.NoError(t, err)
+	assert.Equal(t, "07a37cbc142093c8b755dc1b10e86cb426374ad16aa853ed0bdfc0b2b86d1c7c", hex.EncodeToString(k[KeySize:]))
+
+	opened, err := k.OpenAnonymous(sealed)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "libsodium sealed box", string(opened))
+	}
+}
@@ -0,0 +1,24 @@
+package crypt
+
+import "errors"
+
+// DecryptResult is the result of decrypting a single message of a batch.
+type DecryptResult struct {
+	PublicKey PublicKey
+	Plaintext []byte
+	Err       error
+}
+
+// DecryptBatch decrypts each of the messages. Failures are reported per message in the results rather than aborting
+// the batch, so the returned error is only non-nil if msgs is nil.
+func (key PrivateKey) DecryptBatch(msgs [][]byte) ([]DecryptResult, error) {
+	if msgs == nil {
+		return nil, errors.New("no messages")
+	}
+
+	results := make([]DecryptResult, len(msgs))
+	for i, msg := range msgs {
+		results[i].PublicKey, results[i].Plaintext, results[i].Err = key.Decrypt(msg)
+	}
+	return results, nil
+}
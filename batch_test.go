@@ -0,0 +1,44 @@
+package crypt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecryptBatch(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	corrupt := k1.Encrypt(k2.PublicKey(), []byte("corrupt"))
+	corrupt[len(corrupt)-1] ^= 0xff
+
+	results, err := k2.DecryptBatch([][]byte{
+		k1.Encrypt(k2.PublicKey(), []byte("first")),
+		corrupt,
+		[]byte("short"),
+		k1.Encrypt(k2.PublicKey(), []byte("last")),
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, results, 4) {
+		assert.NoError(t, results[0].Err)
+		assert.Equal(t, k1.PublicKey(), results[0].PublicKey)
+		assert.Equal(t, "first", string(results[0].Plaintext))
+
+		assert.Error(t, results[1].Err)
+		assert.Nil(t, results[1].Plaintext)
+		assert.Error(t, results[2].Err)
+
+		assert.NoError(t, results[3].Err)
+		assert.Equal(t, "last", string(results[3].Plaintext))
+	}
+
+	results, err = k2.DecryptBatch([][]byte{})
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+
+	_, err = k2.DecryptBatch(nil)
+	assert.Error(t, err)
+}
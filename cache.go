@@ -0,0 +1,91 @@
+package crypt
+
+import (
+	"container/list"
+	"sync"
+
+	"golang.org/x/crypto/nacl/box"
+)
+
+// SharedKeyCache encrypts messages to many peers, caching the shared key for each peer so it is only precomputed
+// once. Only the most recently used shared keys are kept.
+//
+// A SharedKeyCache is safe for concurrent use.
+type SharedKeyCache struct {
+	self PrivateKey
+	max  int
+
+	mu    sync.Mutex
+	order *list.List
+	keys  map[PublicKey]*list.Element
+}
+
+type sharedKeyCacheEntry struct {
+	peer PublicKey
+	key  SharedKey
+}
+
+// NewSharedKeyCache creates a new SharedKeyCache for messages sent by self which keeps the shared keys of the last
+// max peers.
+func NewSharedKeyCache(self PrivateKey, max int) *SharedKeyCache {
+	if max < 1 {
+		max = 1
+	}
+	return &SharedKeyCache{
+		self:  self,
+		max:   max,
+		order: list.New(),
+		keys:  make(map[PublicKey]*list.Element, max),
+	}
+}
+
+// Len returns the number of cached shared keys.
+func (c *SharedKeyCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+// SharedKey returns the shared key for the peer public key, precomputing it if it isn't cached.
+func (c *SharedKeyCache) SharedKey(peer PublicKey) SharedKey {
+	c.mu.Lock()
+	if elem, ok := c.keys[peer]; ok {
+		c.order.MoveToFront(elem)
+		key := elem.Value.(*sharedKeyCacheEntry).key
+		c.mu.Unlock()
+		return key
+	}
+	c.mu.Unlock()
+
+	// precompute outside of the lock, it's the expensive part
+	key := c.self.Precompute(peer)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.keys[peer]; ok {
+		c.order.MoveToFront(elem)
+		return key
+	}
+	c.keys[peer] = c.order.PushFront(&sharedKeyCacheEntry{peer: peer, key: key})
+	if c.order.Len() > c.max {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.keys, oldest.Value.(*sharedKeyCacheEntry).peer)
+	}
+	return key
+}
+
+// Encrypt is like PrivateKey.Encrypt, but uses the cached shared key for the peer public key. Encrypt panics if the
+// source of randomness fails.
+func (c *SharedKeyCache) Encrypt(peer PublicKey, data []byte) []byte {
+	shared := [KeySize]byte(c.SharedKey(peer))
+	nonce := mustGenerateNonce()
+
+	result := make([]byte, 0, HeaderSize+KeySize+NonceSize+len(data)+box.Overhead)
+	result = appendHeader(result, MessageTypeBox)
+	result = append(result, c.self[KeySize:]...)
+	result = append(result, nonce[:]...)
+	return box.SealAfterPrecomputation(result, data, nonce.ptr(), &shared)
+}
@@ -0,0 +1,89 @@
+package crypt
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSharedKeyCache(t *testing.T) {
+	self, err := Generate()
+	assert.NoError(t, err)
+
+	cache := NewSharedKeyCache(self, 2)
+
+	var peers []PrivateKey
+	for i := 0; i < 3; i++ {
+		peer, err := Generate()
+		assert.NoError(t, err)
+		peers = append(peers, peer)
+	}
+
+	for _, peer := range peers {
+		encrypted := cache.Encrypt(peer.PublicKey(), []byte("Hello World"))
+		sender, decrypted, err := peer.Decrypt(encrypted)
+		if assert.NoError(t, err) {
+			assert.Equal(t, self.PublicKey(), sender)
+			assert.Equal(t, "Hello World", string(decrypted))
+		}
+		assert.Equal(t, self.Precompute(peer.PublicKey()), cache.SharedKey(peer.PublicKey()))
+	}
+	assert.Equal(t, 2, cache.Len(), "only the last peers should be cached")
+
+	t.Run("Eviction", func(t *testing.T) {
+		cache := NewSharedKeyCache(self, 2)
+		cache.SharedKey(peers[0].PublicKey())
+		cache.SharedKey(peers[1].PublicKey())
+		// using the first peer again makes the second the least recently used
+		cache.SharedKey(peers[0].PublicKey())
+		cache.SharedKey(peers[2].PublicKey())
+
+		_, ok := cache.keys[peers[0].PublicKey()]
+		assert.True(t, ok)
+		_, ok = cache.keys[peers[1].PublicKey()]
+		assert.False(t, ok)
+		_, ok = cache.keys[peers[2].PublicKey()]
+		assert.True(t, ok)
+	})
+
+	t.Run("Concurrent", func(t *testing.T) {
+		cache := NewSharedKeyCache(self, 2)
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				peer := peers[i%len(peers)]
+				for j := 0; j < 20; j++ {
+					_, decrypted, err := peer.Decrypt(cache.Encrypt(peer.PublicKey(), []byte("Hello World")))
+					assert.NoError(t, err)
+					assert.Equal(t, "Hello World", string(decrypted))
+				}
+			}(i)
+		}
+		wg.Wait()
+		assert.Equal(t, 2, cache.Len())
+	})
+}
+
+func BenchmarkSharedKeyCacheEncrypt(b *testing.B) {
+	self, _ := Generate()
+	peers := make([]PublicKey, 1000)
+	for i := range peers {
+		k, _ := Generate()
+		peers[i] = k.PublicKey()
+	}
+	cache := NewSharedKeyCache(self, len(peers))
+	msg := make([]byte, 256)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			cache.Encrypt(peers[i%len(peers)], msg)
+			i++
+		}
+	})
+}
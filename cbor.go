@@ -0,0 +1,33 @@
+package crypt
+
+import (
+	"github.com/fxamacker/cbor/v2"
+)
+
+// MarshalCBOR implements cbor.Marshaler. The raw 64 bytes of the key are encoded as a CBOR byte string.
+func (key PrivateKey) MarshalCBOR() ([]byte, error) {
+	return cbor.Marshal(key[:])
+}
+
+// UnmarshalCBOR implements cbor.Unmarshaler.
+func (key *PrivateKey) UnmarshalCBOR(data []byte) error {
+	var bs []byte
+	if err := cbor.Unmarshal(data, &bs); err != nil {
+		return err
+	}
+	return key.UnmarshalBinary(bs)
+}
+
+// MarshalCBOR implements cbor.Marshaler. The raw 32 bytes of the key are encoded as a CBOR byte string.
+func (key PublicKey) MarshalCBOR() ([]byte, error) {
+	return cbor.Marshal(key[:])
+}
+
+// UnmarshalCBOR implements cbor.Unmarshaler.
+func (key *PublicKey) UnmarshalCBOR(data []byte) error {
+	var bs []byte
+	if err := cbor.Unmarshal(data, &bs); err != nil {
+		return err
+	}
+	return key.UnmarshalBinary(bs)
+}
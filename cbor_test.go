@@ -0,0 +1,50 @@
+package crypt
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCBOR(t *testing.T) {
+	priv, err := Generate()
+	assert.NoError(t, err)
+	pub := priv.PublicKey()
+
+	type record struct {
+		Private PrivateKey
+		Public  PublicKey
+	}
+
+	bs, err := cbor.Marshal(record{Private: priv, Public: pub})
+	assert.NoError(t, err)
+
+	var decoded record
+	if assert.NoError(t, cbor.Unmarshal(bs, &decoded)) {
+		assert.Equal(t, priv, decoded.Private)
+		assert.Equal(t, pub, decoded.Public)
+	}
+
+	// keys are encoded as byte strings with a one byte length
+	bs, err = cbor.Marshal(pub)
+	assert.NoError(t, err)
+	assert.Equal(t, append([]byte{0x58, KeySize}, pub[:]...), bs)
+
+	bs, err = cbor.Marshal(pub[:KeySize-1])
+	assert.NoError(t, err)
+	var decodedPub PublicKey
+	assert.Error(t, cbor.Unmarshal(bs, &decodedPub))
+
+	bs, err = cbor.Marshal(priv[:KeySize+1])
+	assert.NoError(t, err)
+	var decodedPriv PrivateKey
+	assert.True(t, errors.Is(cbor.Unmarshal(bs, &decodedPriv), ErrInvalidKeyLength))
+
+	corrupted := priv
+	corrupted[KeySize] ^= 0xff
+	bs, err = cbor.Marshal(corrupted)
+	assert.NoError(t, err)
+	assert.True(t, errors.Is(cbor.Unmarshal(bs, &decodedPriv), ErrKeyMismatch))
+}
@@ -0,0 +1,54 @@
+package crypt
+
+import (
+	"fmt"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// EncryptChaCha encrypts data using XChaCha20-Poly1305 rather than the XSalsa20-Poly1305 of nacl/box, binding the
+// result to the associated data. The key is the same shared key Precompute returns. The result is the nonce
+// followed by the ciphertext, neither a header nor the sender's public key are included.
+func (key PrivateKey) EncryptChaCha(peer PublicKey, data, aad []byte) ([]byte, error) {
+	if err := peer.Validate(); err != nil {
+		return nil, err
+	}
+
+	shared := key.Precompute(peer)
+	aead, err := chacha20poly1305.NewX(shared[:])
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := generateNonce()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]byte, 0, len(nonce)+len(data)+aead.Overhead())
+	result = append(result, nonce[:]...)
+	return aead.Seal(result, nonce[:], data, aad), nil
+}
+
+// DecryptChaCha decrypts data that was encrypted via EncryptChaCha by the peer. Decryption fails with ErrOpenFailed
+// if the associated data doesn't match.
+func (key PrivateKey) DecryptChaCha(peer PublicKey, data, aad []byte) ([]byte, error) {
+	if err := peer.Validate(); err != nil {
+		return nil, decryptFailed(fmt.Errorf("invalid message: %w", err))
+	}
+	if len(data) < NonceSize {
+		return nil, decryptFailed(fmt.Errorf("invalid message: expected nonce: %w", ErrMissingNonce))
+	}
+
+	shared := key.Precompute(peer)
+	aead, err := chacha20poly1305.NewX(shared[:])
+	if err != nil {
+		return nil, err
+	}
+
+	opened, err := aead.Open(nil, data[:NonceSize], data[NonceSize:], aad)
+	if err != nil {
+		return nil, decryptFailed(fmt.Errorf("invalid message: %w", ErrOpenFailed))
+	}
+	return opened, nil
+}
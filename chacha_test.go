@@ -0,0 +1,67 @@
+package crypt
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/mr-tron/base58"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+func TestChaCha(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	msg := []byte("Hello World")
+	aad := []byte("header")
+
+	encrypted, err := k1.EncryptChaCha(k2.PublicKey(), msg, aad)
+	assert.NoError(t, err)
+	assert.Len(t, encrypted, NonceSize+len(msg)+16)
+
+	decrypted, err := k2.DecryptChaCha(k1.PublicKey(), encrypted, aad)
+	if assert.NoError(t, err) {
+		assert.Equal(t, msg, decrypted)
+	}
+
+	// the result is plain XChaCha20-Poly1305 under the precomputed shared key
+	shared := k2.Precompute(k1.PublicKey())
+	aead, err := chacha20poly1305.NewX(shared[:])
+	assert.NoError(t, err)
+	opened, err := aead.Open(nil, encrypted[:NonceSize], encrypted[NonceSize:], aad)
+	if assert.NoError(t, err) {
+		assert.Equal(t, msg, opened)
+	}
+
+	_, err = k2.DecryptChaCha(k1.PublicKey(), encrypted, []byte("other"))
+	assert.True(t, errors.Is(err, ErrOpenFailed))
+	_, err = k2.DecryptChaCha(k1.PublicKey(), encrypted[:NonceSize-1], aad)
+	assert.True(t, errors.Is(err, ErrMissingNonce))
+	_, err = k2.DecryptChaCha(PublicKey{}, encrypted, aad)
+	assert.True(t, errors.Is(err, ErrLowOrderPoint))
+	_, err = k1.EncryptChaCha(PublicKey{}, msg, aad)
+	assert.True(t, errors.Is(err, ErrLowOrderPoint))
+}
+
+func TestChaChaLibsodium(t *testing.T) {
+	// generated with libsodium's crypto_aead_xchacha20poly1305_ietf_encrypt using the crypto_box_beforenm key, the
+	// nonce prepended
+	sender, _ := hex.DecodeString("0102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f20")
+	recipient, _ := hex.DecodeString("2122232425262728292a2b2c2d2e2f303132333435363738393a3b3c3d3e3f40")
+	encrypted, _ := hex.DecodeString("4142434445464748494a4b4c4d4e4f505152535455565758" +
+		"a0131d8eff6cc8ad4c394554e085a809b06673d4f152c37a9f027ebd128baa61e4")
+
+	k1, err := NewPrivateKey(base58.Encode(sender))
+	assert.NoError(t, err)
+	k2, err := NewPrivateKey(base58.Encode(recipient))
+	assert.NoError(t, err)
+
+	decrypted, err := k2.DecryptChaCha(k1.PublicKey(), encrypted, []byte("header"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "xchacha20poly1305", string(decrypted))
+	}
+}
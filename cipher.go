@@ -0,0 +1,50 @@
+package crypt
+
+import (
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/nacl/box"
+)
+
+// Cipher seals and opens messages exchanged with a single peer. It holds the precomputed shared key and scratch
+// space for the nonce so sealing and opening into a buffer with enough capacity doesn't allocate.
+//
+// Messages use the same layout as SharedKey.Seal: the nonce followed by the ciphertext.
+//
+// A Cipher may be reused for any number of messages, but it is not safe for concurrent use.
+type Cipher struct {
+	sharedKey [KeySize]byte
+	nonce     Nonce
+}
+
+// NewCipher creates a new Cipher for messages exchanged with the peer public key.
+func (key PrivateKey) NewCipher(peer PublicKey) *Cipher {
+	return &Cipher{sharedKey: [KeySize]byte(key.Precompute(peer))}
+}
+
+// Seal encrypts data, appends the message to dst and returns the resulting slice. Seal panics if the source of
+// randomness fails.
+func (c *Cipher) Seal(dst, data []byte) []byte {
+	if _, err := io.ReadFull(randReader, c.nonce[:]); err != nil {
+		panic(fmt.Errorf("failed to generate nonce: %w", err))
+	}
+
+	dst = append(dst, c.nonce[:]...)
+	return box.SealAfterPrecomputation(dst, data, c.nonce.ptr(), &c.sharedKey)
+}
+
+// Open decrypts a message sealed for the same pair of keys, appends the plaintext to dst and returns the resulting
+// slice.
+func (c *Cipher) Open(dst, data []byte) ([]byte, error) {
+	if len(data) < NonceSize {
+		return nil, decryptFailed(fmt.Errorf("invalid message: expected nonce: %w", ErrMissingNonce))
+	}
+
+	copy(c.nonce[:], data)
+	opened, ok := box.OpenAfterPrecomputation(dst, data[NonceSize:], c.nonce.ptr(), &c.sharedKey)
+	if !ok {
+		return nil, decryptFailed(fmt.Errorf("invalid message: %w", ErrOpenFailed))
+	}
+	return opened, nil
+}
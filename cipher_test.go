@@ -0,0 +1,80 @@
+package crypt
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCipher(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	c1 := k1.NewCipher(k2.PublicKey())
+	c2 := k2.NewCipher(k1.PublicKey())
+
+	msg := []byte("Hello World")
+
+	sealed := c1.Seal(nil, msg)
+	opened, err := c2.Open(nil, sealed)
+	if assert.NoError(t, err) {
+		assert.Equal(t, msg, opened)
+	}
+	assert.NotEqual(t, sealed, c1.Seal(nil, msg), "every message should use a fresh nonce")
+
+	// the layout matches SharedKey
+	opened, err = k2.Precompute(k1.PublicKey()).Open(sealed)
+	if assert.NoError(t, err) {
+		assert.Equal(t, msg, opened)
+	}
+	opened, err = c2.Open(nil, k1.Precompute(k2.PublicKey()).Seal(msg))
+	if assert.NoError(t, err) {
+		assert.Equal(t, msg, opened)
+	}
+
+	buf := make([]byte, 0, 64)
+	sealed = c1.Seal(buf[:3], msg)
+	assert.Equal(t, &buf[:1][0], &sealed[0], "the message should be appended to dst")
+	opened, err = c2.Open([]byte("abc"), sealed[3:])
+	if assert.NoError(t, err) {
+		assert.Equal(t, "abcHello World", string(opened))
+	}
+
+	sealed[len(sealed)-1] ^= 0xff
+	_, err = c2.Open(nil, sealed[3:])
+	assert.True(t, errors.Is(err, ErrOpenFailed))
+	_, err = c2.Open(nil, sealed[:NonceSize-1])
+	assert.True(t, errors.Is(err, ErrMissingNonce))
+}
+
+func BenchmarkCipherSeal(b *testing.B) {
+	k1, _ := Generate()
+	k2, _ := Generate()
+	c := k1.NewCipher(k2.PublicKey())
+	msg := make([]byte, 256)
+	buf := make([]byte, 0, 512)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.Seal(buf[:0], msg)
+	}
+}
+
+func BenchmarkCipherOpen(b *testing.B) {
+	k1, _ := Generate()
+	k2, _ := Generate()
+	c1 := k1.NewCipher(k2.PublicKey())
+	c2 := k2.NewCipher(k1.PublicKey())
+	sealed := c1.Seal(nil, make([]byte, 256))
+	buf := make([]byte, 0, 256)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = c2.Open(buf[:0], sealed)
+	}
+}
@@ -0,0 +1,97 @@
+package crypt
+
+import (
+	"errors"
+
+	"filippo.io/edwards25519"
+)
+
+// CombinePublicKeys combines two public keys into a single public key for 2-of-2 encryption: messages encrypted to
+// the combined key can only be decrypted by the key returned by CombinePrivateKeys, which needs both private keys.
+// The combined key is the sum of the two keys as Edwards points, using the same sign convention as Sign, so it
+// doesn't depend on the order of the arguments. The zero key is returned if either key isn't a valid point or is a
+// low-order point.
+//
+// Combining is only secure if both public keys were fixed before either party saw the other's key, or if each
+// party proved possession of its private key, for example by signing a challenge. Otherwise whoever contributes
+// the second key can pick it so the combined key is one they control alone.
+func CombinePublicKeys(a, b PublicKey) PublicKey {
+	if a.Validate() != nil || b.Validate() != nil {
+		return PublicKey{}
+	}
+
+	pa, ok := a.edwardsPoint()
+	if !ok {
+		return PublicKey{}
+	}
+	pb, ok := b.edwardsPoint()
+	if !ok {
+		return PublicKey{}
+	}
+
+	var combined PublicKey
+	copy(combined[:], new(edwards25519.Point).Add(pa, pb).BytesMontgomery())
+	if combined.Validate() != nil {
+		return PublicKey{}
+	}
+	return combined
+}
+
+// CombinePrivateKeys combines two private keys into the private key for the public key that CombinePublicKeys
+// returns for their public keys. See CombinePublicKeys for the security model.
+func CombinePrivateKeys(a, b PrivateKey) (PrivateKey, error) {
+	sa, _ := a.edwards()
+	sb, _ := b.edwards()
+	sum := edwards25519.NewScalar().Add(sa, sb)
+
+	// X25519 clamps private scalars, so the sum modulo the group order has to be represented by a clamped scalar: a
+	// multiple of 8 with bit 254 set. Negating the sum results in the same X25519 public key, and for almost all
+	// sums one of the two can be represented.
+	scalar, ok := clampedScalar(sum)
+	if !ok {
+		scalar, ok = clampedScalar(edwards25519.NewScalar().Negate(sum))
+	}
+	if !ok {
+		return PrivateKey{}, errors.New("invalid key: combined key can't be represented")
+	}
+
+	combined, err := newPrivateKeyFromScalar(scalar)
+	if err != nil {
+		return combined, err
+	}
+	if combined.PublicKey().Validate() != nil {
+		return PrivateKey{}, errors.New("invalid key: combined key is a low order point")
+	}
+	return combined, nil
+}
+
+// clampedScalar returns the clamped scalar t = 2^254 + 8k congruent to s modulo the group order, if there is one.
+func clampedScalar(s *edwards25519.Scalar) ([]byte, bool) {
+	var wide [64]byte
+	wide[31] = 0x40
+	twoTo254, _ := edwards25519.NewScalar().SetUniformBytes(wide[:])
+
+	wide[31] = 0
+	wide[0] = 8
+	eight, _ := edwards25519.NewScalar().SetUniformBytes(wide[:])
+
+	// k = (s - 2^254) / 8
+	k := edwards25519.NewScalar().Subtract(s, twoTo254)
+	k.Multiply(k, edwards25519.NewScalar().Invert(eight))
+
+	kb := k.Bytes()
+	if kb[31] >= 0x08 {
+		// k must be below 2^251 for t to be below 2^255
+		return nil, false
+	}
+
+	// t = 8k + 2^254, computed on the little endian bytes
+	t := make([]byte, KeySize)
+	var carry byte
+	for i := range kb {
+		t[i] = kb[i]<<3 | carry
+		carry = kb[i] >> 5
+	}
+	t[31] |= 0x40
+	return t, true
+}
@@ -0,0 +1,55 @@
+package crypt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCombineKeys(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		a, err := Generate()
+		assert.NoError(t, err)
+		b, err := Generate()
+		assert.NoError(t, err)
+
+		combinedPub := CombinePublicKeys(a.PublicKey(), b.PublicKey())
+		assert.False(t, combinedPub.IsZero())
+		assert.Equal(t, combinedPub, CombinePublicKeys(b.PublicKey(), a.PublicKey()), "the order should not matter")
+		assert.NotEqual(t, a.PublicKey(), combinedPub)
+		assert.NotEqual(t, b.PublicKey(), combinedPub)
+
+		combined, err := CombinePrivateKeys(a, b)
+		if !assert.NoError(t, err) {
+			continue
+		}
+		assert.Equal(t, combinedPub, combined.PublicKey())
+		swapped, err := CombinePrivateKeys(b, a)
+		assert.NoError(t, err)
+		assert.Equal(t, combined, swapped)
+
+		// only the combined private key decrypts messages for the combined public key
+		sender, err := Generate()
+		assert.NoError(t, err)
+		encrypted := sender.Encrypt(combinedPub, []byte("Hello World"))
+		_, decrypted, err := combined.Decrypt(encrypted)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "Hello World", string(decrypted))
+		}
+		_, _, err = a.Decrypt(encrypted)
+		assert.Error(t, err)
+		_, _, err = b.Decrypt(encrypted)
+		assert.Error(t, err)
+	}
+
+	k, err := Generate()
+	assert.NoError(t, err)
+	assert.True(t, CombinePublicKeys(k.PublicKey(), PublicKey{}).IsZero())
+
+	// non-canonical encodings aren't valid points
+	var invalid PublicKey
+	for i := range invalid {
+		invalid[i] = 0xff
+	}
+	assert.True(t, CombinePublicKeys(invalid, k.PublicKey()).IsZero())
+}
@@ -0,0 +1,75 @@
+package crypt
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/nacl/box"
+)
+
+// MaxDecompressedSize is the maximum size in bytes a compressed message may inflate to. It guards against
+// decompression bombs.
+var MaxDecompressedSize int64 = 64 * 1024 * 1024
+
+// ErrDecompressedTooLarge indicates that a compressed message inflates to more than MaxDecompressedSize bytes.
+var ErrDecompressedTooLarge = errors.New("decompressed message too large")
+
+// EncryptCompressed is like Encrypt, but gzip compresses the data before encrypting it. The message is marked as
+// compressed in its header.
+func (key PrivateKey) EncryptCompressed(peersPublicKey PublicKey, data []byte) []byte {
+	compressed := compress(data)
+	result := make([]byte, 0, HeaderSize+KeySize+NonceSize+len(compressed)+box.Overhead)
+	return key.encryptAppend(result, peersPublicKey, mustGenerateNonce(), MessageTypeCompressed, compressed)
+}
+
+// compress returns the gzip compressed data sealed in a compressed message.
+func compress(data []byte) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	// writes to a bytes.Buffer can't fail
+	_, _ = zw.Write(data)
+	_ = zw.Close()
+	return buf.Bytes()
+}
+
+// DecryptCompressed decrypts data that was encrypted via EncryptCompressed and inflates it. Decrypt handles
+// compressed messages as well, DecryptCompressed differs in rejecting messages that aren't compressed.
+func (key PrivateKey) DecryptCompressed(data []byte) (PublicKey, []byte, error) {
+	typ, body, ok := parseHeader(data)
+	if !ok || typ != MessageTypeCompressed {
+		return PublicKey{}, nil, decryptFailed(errors.New("invalid message: not compressed"))
+	}
+	pub, opened, err := key.decryptCompressed(nil, body)
+	if err != nil {
+		return pub, nil, decryptFailed(err)
+	}
+	return pub, opened, nil
+}
+
+// decryptCompressed decrypts and inflates a compressed message without its header. The plaintext is appended to
+// dst.
+func (key PrivateKey) decryptCompressed(dst, data []byte) (PublicKey, []byte, error) {
+	pub, compressed, err := key.decryptBox(nil, MessageTypeCompressed, data)
+	if err != nil {
+		return pub, nil, err
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return pub, nil, fmt.Errorf("invalid message: %w", err)
+	}
+
+	buf := bytes.NewBuffer(dst)
+	n, err := io.Copy(buf, io.LimitReader(zr, MaxDecompressedSize+1))
+	if err != nil {
+		return pub, nil, fmt.Errorf("invalid message: %w", err)
+	}
+	if n > MaxDecompressedSize {
+		return pub, nil, fmt.Errorf("invalid message: %w", ErrDecompressedTooLarge)
+	}
+
+	return pub, buf.Bytes(), nil
+}
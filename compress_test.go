@@ -0,0 +1,68 @@
+package crypt
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncryptCompressed(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	compressible := bytes.Repeat([]byte(`{"key":"value"},`), 1024)
+	incompressible := make([]byte, 16*1024)
+	_, err = io.ReadFull(rand.Reader, incompressible)
+	assert.NoError(t, err)
+
+	for name, msg := range map[string][]byte{
+		"Compressible":   compressible,
+		"Incompressible": incompressible,
+		"Empty":          {},
+	} {
+		t.Run(name, func(t *testing.T) {
+			encrypted := k1.EncryptCompressed(k2.PublicKey(), msg)
+			if name == "Compressible" {
+				assert.Less(t, len(encrypted), len(msg)/10)
+			}
+
+			pub, decrypted, err := k2.DecryptCompressed(encrypted)
+			if assert.NoError(t, err) {
+				assert.Equal(t, k1.PublicKey(), pub)
+				assert.True(t, bytes.Equal(msg, decrypted))
+			}
+
+			_, decrypted, err = k2.Decrypt(encrypted)
+			if assert.NoError(t, err) {
+				assert.True(t, bytes.Equal(msg, decrypted))
+			}
+		})
+	}
+
+	_, _, err = k2.DecryptCompressed(k1.Encrypt(k2.PublicKey(), compressible))
+	assert.Error(t, err)
+}
+
+func TestDecryptCompressedTooLarge(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	defer func(max int64) { MaxDecompressedSize = max }(MaxDecompressedSize)
+	MaxDecompressedSize = 1024
+
+	encrypted := k1.EncryptCompressed(k2.PublicKey(), make([]byte, 1024))
+	_, _, err = k2.DecryptCompressed(encrypted)
+	assert.NoError(t, err)
+
+	encrypted = k1.EncryptCompressed(k2.PublicKey(), make([]byte, 1025))
+	_, _, err = k2.DecryptCompressed(encrypted)
+	assert.True(t, errors.Is(err, ErrDecompressedTooLarge))
+}
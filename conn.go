@@ -0,0 +1,220 @@
+package crypt
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	"golang.org/x/crypto/hkdf"
+	"golang.org/x/crypto/nacl/box"
+)
+
+const (
+	// connFrameLengthSize is the size of the length prefixed to every frame of a wrapped connection.
+	connFrameLengthSize = 4
+	// connSaltSize is the size of the random salt each side of a wrapped connection sends when it is first used.
+	connSaltSize = 32
+)
+
+// connKeyInfo is the HKDF label of the keys of a wrapped connection.
+var connKeyInfo = []byte("rtctunnel/crypt conn")
+
+type encryptedConn struct {
+	net.Conn
+
+	key  PrivateKey
+	peer PublicKey
+
+	handshakeOnce sync.Once
+	handshakeErr  error
+
+	readMu      sync.Mutex
+	readKey     [KeySize]byte
+	readCounter uint64
+	readErr     error
+	frame       []byte
+	plaintext   []byte
+	buf         []byte
+
+	writeMu      sync.Mutex
+	writeKey     [KeySize]byte
+	writeCounter uint64
+	writeErr     error
+	out          []byte
+}
+
+// WrapConn returns a connection which encrypts everything written to it for the peer public key and decrypts
+// everything read from it, which the peer must have wrapped the same way. Writes are split into chunks of at most
+// StreamChunkSize bytes, each sealed and sent as a length-prefixed frame. Reads return the plaintext of a frame only
+// after the whole frame was received and authenticated.
+//
+// When the connection is first read from or written to, both sides exchange a random salt. Each direction is then
+// sealed with its own key, derived from the shared key, both salts and the public keys of sender and receiver, and
+// frames are numbered by their nonce. So frames reflected back to their sender, replayed from this or an earlier
+// connection, reordered or dropped all fail to open. A connection closed at a frame boundary can't be told apart
+// from a regular close, protocols that need to know must mark their end themselves.
+//
+// Once a Read or Write fails every later call fails with the same error, as the frame numbering can't be kept in
+// step after a lost or partial frame. Like any net.Conn the returned connection is safe for concurrent use.
+func (key PrivateKey) WrapConn(conn net.Conn, peer PublicKey) net.Conn {
+	return &encryptedConn{
+		Conn: conn,
+		key:  key,
+		peer: peer,
+	}
+}
+
+// handshake exchanges salts with the peer and derives the keys of both directions.
+func (c *encryptedConn) handshake() error {
+	c.handshakeOnce.Do(func() {
+		c.handshakeErr = c.exchangeSalts()
+	})
+	return c.handshakeErr
+}
+
+func (c *encryptedConn) exchangeSalts() error {
+	if err := c.peer.Validate(); err != nil {
+		return err
+	}
+
+	var local, remote [connSaltSize]byte
+	if _, err := io.ReadFull(randReader, local[:]); err != nil {
+		return fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	// the salts are sent and received at the same time, so neither side waits for the other to read
+	written := make(chan error, 1)
+	go func() {
+		_, err := c.Conn.Write(local[:])
+		written <- err
+	}()
+	_, readErr := io.ReadFull(c.Conn, remote[:])
+	if err := <-written; err != nil {
+		return err
+	}
+	if readErr != nil {
+		if readErr == io.EOF {
+			readErr = io.ErrUnexpectedEOF
+		}
+		return fmt.Errorf("invalid connection: expected salt: %w", readErr)
+	}
+
+	shared := c.key.Precompute(c.peer)
+	self := c.key.PublicKey()
+	if err := connKey(&c.writeKey, shared, local, remote, self, c.peer); err != nil {
+		return err
+	}
+	return connKey(&c.readKey, shared, remote, local, c.peer, self)
+}
+
+// connKey derives the key of the direction from sender to receiver.
+func connKey(dst *[KeySize]byte, shared SharedKey, senderSalt, receiverSalt [connSaltSize]byte, sender, receiver PublicKey) error {
+	salt := make([]byte, 0, 2*connSaltSize)
+	salt = append(salt, senderSalt[:]...)
+	salt = append(salt, receiverSalt[:]...)
+
+	info := make([]byte, 0, len(connKeyInfo)+2*KeySize)
+	info = append(info, connKeyInfo...)
+	info = append(info, sender[:]...)
+	info = append(info, receiver[:]...)
+
+	_, err := io.ReadFull(hkdf.New(sha256.New, shared[:], salt, info), dst[:])
+	return err
+}
+
+func (c *encryptedConn) Read(p []byte) (int, error) {
+	if err := c.handshake(); err != nil {
+		return 0, err
+	}
+
+	c.readMu.Lock()
+	defer c.readMu.Unlock()
+
+	for len(c.buf) == 0 {
+		if c.readErr != nil {
+			return 0, c.readErr
+		}
+		// a frame that failed to open leaves the counter out of step, so errors are permanent
+		c.readErr = c.readFrame()
+	}
+
+	n := copy(p, c.buf)
+	c.buf = c.buf[n:]
+	return n, nil
+}
+
+func (c *encryptedConn) readFrame() error {
+	var header [connFrameLengthSize]byte
+	if _, err := io.ReadFull(c.Conn, header[:]); err != nil {
+		// io.EOF is only returned at a frame boundary
+		return err
+	}
+
+	n := binary.BigEndian.Uint32(header[:])
+	if n < box.Overhead || n > uint32(maxStreamChunkSize())+box.Overhead {
+		return decryptFailed(fmt.Errorf("invalid connection: invalid frame length %d", n))
+	}
+
+	if cap(c.frame) < int(n) {
+		c.frame = make([]byte, n)
+	}
+	frame := c.frame[:n]
+	if _, err := io.ReadFull(c.Conn, frame); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return decryptFailed(fmt.Errorf("invalid connection: expected frame: %w", err))
+	}
+
+	nonce := NonceForSequence([sessionNoncePrefixSize]byte{}, c.readCounter)
+	opened, ok := box.OpenAfterPrecomputation(c.plaintext[:0], frame, nonce.ptr(), &c.readKey)
+	if !ok {
+		return decryptFailed(fmt.Errorf("invalid connection: frame %d: %w", c.readCounter, ErrOpenFailed))
+	}
+	c.readCounter++
+	c.plaintext = opened
+	c.buf = opened
+	return nil
+}
+
+func (c *encryptedConn) Write(p []byte) (int, error) {
+	if err := c.handshake(); err != nil {
+		return 0, err
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	// after a failed write the peer may have received part of a frame or none of it, either way the counter is out
+	// of step, so errors are permanent
+	if c.writeErr != nil {
+		return 0, c.writeErr
+	}
+
+	chunkSize := maxStreamChunkSize()
+	n := 0
+	for len(p) > 0 {
+		chunk := p
+		if len(chunk) > chunkSize {
+			chunk = chunk[:chunkSize]
+		}
+
+		nonce := NonceForSequence([sessionNoncePrefixSize]byte{}, c.writeCounter)
+		c.writeCounter++
+
+		c.out = append(c.out[:0], 0, 0, 0, 0)
+		c.out = box.SealAfterPrecomputation(c.out, chunk, nonce.ptr(), &c.writeKey)
+		binary.BigEndian.PutUint32(c.out, uint32(len(c.out)-connFrameLengthSize))
+		if _, err := c.Conn.Write(c.out); err != nil {
+			c.writeErr = err
+			return n, err
+		}
+
+		p = p[len(chunk):]
+		n += len(chunk)
+	}
+	return n, nil
+}
@@ -0,0 +1,272 @@
+package crypt
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrapConn(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	c1, c2 := net.Pipe()
+	conn1 := k1.WrapConn(c1, k2.PublicKey())
+	conn2 := k2.WrapConn(c2, k1.PublicKey())
+
+	// interleave small writes and writes spanning several frames
+	var writes [][]byte
+	for _, size := range []int{1, 10, StreamChunkSize*2 + 10, 3, StreamChunkSize, 0, 1000} {
+		b := make([]byte, size)
+		_, err := io.ReadFull(rand.Reader, b)
+		assert.NoError(t, err)
+		writes = append(writes, b)
+	}
+	expected := bytes.Join(writes, nil)
+
+	go func() {
+		for _, b := range writes {
+			n, err := conn1.Write(b)
+			assert.NoError(t, err)
+			assert.Equal(t, len(b), n)
+		}
+		conn1.Close()
+	}()
+
+	// read in odd sized pieces so reads don't line up with frames
+	var received []byte
+	buf := make([]byte, 777)
+	for {
+		n, err := conn2.Read(buf)
+		received = append(received, buf[:n]...)
+		if err == io.EOF {
+			break
+		}
+		if !assert.NoError(t, err) {
+			break
+		}
+	}
+	assert.True(t, bytes.Equal(expected, received))
+}
+
+// relayConn connects a sender and a receiver wrapping the two ends through a relay, which passes the salts on
+// unchanged and the frames from sender to receiver through filter, one byte at a time. It returns the receiver's
+// connection.
+func relayConn(t *testing.T, sender, receiver PrivateKey, writes []string, filter func(frames [][]byte) [][]byte) net.Conn {
+	s1, s2 := net.Pipe()
+	r1, r2 := net.Pipe()
+
+	go func() {
+		conn := sender.WrapConn(s1, receiver.PublicKey())
+		for _, w := range writes {
+			_, err := conn.Write([]byte(w))
+			assert.NoError(t, err)
+		}
+		conn.Close()
+	}()
+
+	// receiver to sender: only the salt is ever sent
+	go func() {
+		salt := make([]byte, connSaltSize)
+		if _, err := io.ReadFull(r2, salt); err == nil {
+			_, _ = s2.Write(salt)
+		}
+	}()
+
+	// sender to receiver: the salt, then the filtered frames
+	go func() {
+		defer r2.Close()
+
+		salt := make([]byte, connSaltSize)
+		if _, err := io.ReadFull(s2, salt); err != nil {
+			return
+		}
+		_, _ = r2.Write(salt)
+
+		var frames [][]byte
+		for range writes {
+			var header [connFrameLengthSize]byte
+			if _, err := io.ReadFull(s2, header[:]); err != nil {
+				return
+			}
+			frame := make([]byte, connFrameLengthSize+int(binary.BigEndian.Uint32(header[:])))
+			copy(frame, header[:])
+			if _, err := io.ReadFull(s2, frame[connFrameLengthSize:]); err != nil {
+				return
+			}
+			frames = append(frames, frame)
+		}
+		for _, b := range bytes.Join(filter(frames), nil) {
+			if _, err := r2.Write([]byte{b}); err != nil {
+				return
+			}
+		}
+	}()
+
+	return receiver.WrapConn(r1, sender.PublicKey())
+}
+
+func TestWrapConnRelay(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	writes := []string{"Hello", " ", "World"}
+	for _, tc := range []struct {
+		name     string
+		filter   func(frames [][]byte) [][]byte
+		received string
+		err      error
+	}{
+		{"PartialReads", func(frames [][]byte) [][]byte { return frames }, "Hello World", nil},
+		{"Replay", func(frames [][]byte) [][]byte { return [][]byte{frames[0], frames[0]} }, "Hello", ErrOpenFailed},
+		{"Drop", func(frames [][]byte) [][]byte { return [][]byte{frames[0], frames[2]} }, "Hello", ErrOpenFailed},
+		{"Reorder", func(frames [][]byte) [][]byte { return [][]byte{frames[1], frames[0]} }, "", ErrOpenFailed},
+		{"Truncated", func(frames [][]byte) [][]byte {
+			return [][]byte{frames[0], frames[1][:len(frames[1])-1]}
+		}, "Hello", io.ErrUnexpectedEOF},
+		{"Tampered", func(frames [][]byte) [][]byte {
+			tampered := append([]byte(nil), frames[0]...)
+			tampered[connFrameLengthSize] ^= 0xff
+			return [][]byte{tampered}
+		}, "", ErrOpenFailed},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			conn := relayConn(t, k1, k2, writes, tc.filter)
+			received, err := ioutil.ReadAll(conn)
+			if tc.err == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.Is(err, tc.err), "%v", err)
+			}
+			assert.Equal(t, tc.received, string(received))
+		})
+	}
+}
+
+func TestWrapConnReflection(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	for _, echoSalt := range []bool{false, true} {
+		c1, c2 := net.Pipe()
+		conn := k1.WrapConn(c1, k2.PublicKey())
+
+		// the attacker answers with its own salt or echoes the victim's, then reflects every frame back
+		go func() {
+			salt := make([]byte, connSaltSize)
+			if _, err := io.ReadFull(c2, salt); err != nil {
+				return
+			}
+			if !echoSalt {
+				_, _ = io.ReadFull(rand.Reader, salt)
+			}
+			_, _ = c2.Write(salt)
+			_, _ = io.Copy(c2, c2)
+		}()
+
+		go func() {
+			_, _ = conn.Write([]byte("transfer 100 to mallory"))
+		}()
+		_, err := conn.Read(make([]byte, 100))
+		assert.True(t, errors.Is(err, ErrOpenFailed), "%v", err)
+		conn.Close()
+	}
+}
+
+func TestWrapConnReplayedConnection(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	// record everything the sender sends in one connection
+	var recorded bytes.Buffer
+	s1, s2 := net.Pipe()
+	go func() {
+		conn := k1.WrapConn(s1, k2.PublicKey())
+		_, _ = conn.Write([]byte("Hello World"))
+		conn.Close()
+	}()
+	go func() {
+		salt := make([]byte, connSaltSize)
+		_, _ = io.ReadFull(rand.Reader, salt)
+		_, _ = s2.Write(salt)
+	}()
+	_, err = io.Copy(&recorded, s2)
+	assert.NoError(t, err)
+
+	// and replay it to the receiver of a new connection
+	r1, r2 := net.Pipe()
+	go func() {
+		_, _ = io.ReadFull(r2, make([]byte, connSaltSize))
+	}()
+	go func() {
+		_, _ = r2.Write(recorded.Bytes())
+		r2.Close()
+	}()
+	_, err = ioutil.ReadAll(k2.WrapConn(r1, k1.PublicKey()))
+	assert.True(t, errors.Is(err, ErrOpenFailed), "%v", err)
+}
+
+// failingConn fails writes to the wrapped connection while fail is set.
+type failingConn struct {
+	net.Conn
+	fail   bool
+	writes int
+}
+
+func (c *failingConn) Write(p []byte) (int, error) {
+	c.writes++
+	if c.fail {
+		return 0, errors.New("write failed")
+	}
+	return c.Conn.Write(p)
+}
+
+func TestWrapConnWriteError(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	c1, c2 := net.Pipe()
+	defer c2.Close()
+	failing := &failingConn{Conn: c1}
+	conn := k1.WrapConn(failing, k2.PublicKey())
+	received := make(chan []byte, 1)
+	go func() {
+		b, _ := ioutil.ReadAll(k2.WrapConn(c2, k1.PublicKey()))
+		received <- b
+	}()
+
+	_, err = conn.Write([]byte("Hello"))
+	assert.NoError(t, err)
+
+	failing.fail = true
+	_, err = conn.Write([]byte(" World"))
+	assert.EqualError(t, err, "write failed")
+
+	// later writes fail as well rather than sending frames out of sequence
+	failing.fail = false
+	writes := failing.writes
+	n, err := conn.Write([]byte("!"))
+	assert.EqualError(t, err, "write failed")
+	assert.Equal(t, 0, n)
+	assert.Equal(t, writes, failing.writes)
+
+	c1.Close()
+	assert.Equal(t, "Hello", string(<-received))
+}
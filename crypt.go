@@ -1,12 +1,21 @@
 package crypt
 
 import (
+	"context"
 	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"runtime"
+	"strings"
+	"sync"
 
 	"github.com/mr-tron/base58"
+	"golang.org/x/crypto/curve25519"
 	"golang.org/x/crypto/nacl/box"
 )
 
@@ -17,6 +26,48 @@ const (
 	NonceSize = 24
 )
 
+var (
+	// ErrShortMessage indicates that a message is too short to contain the sender's public key.
+	ErrShortMessage = errors.New("message too short")
+	// ErrMissingNonce indicates that a message is too short to contain a nonce.
+	ErrMissingNonce = errors.New("missing nonce")
+	// ErrOpenFailed indicates that a message could not be authenticated and decrypted.
+	ErrOpenFailed = errors.New("nacl box open failed")
+	// ErrUnknownVersion indicates that a message has a header with an unsupported message type.
+	ErrUnknownVersion = errors.New("unknown message version")
+	// ErrKeyMismatch indicates that a public key does not correspond to a private key.
+	ErrKeyMismatch = errors.New("invalid key: public key does not match private key")
+	// ErrMessageTooLarge indicates that a message exceeds MaxMessageSize.
+	ErrMessageTooLarge = errors.New("message too large")
+	// ErrUnexpectedSender indicates that a message was sent by someone other than the expected peer.
+	ErrUnexpectedSender = errors.New("unexpected sender")
+	// ErrInvalidKeyLength indicates that a decoded key has the wrong number of bytes.
+	ErrInvalidKeyLength = errors.New("invalid key")
+	// ErrPlaintextTooShort indicates that a decrypted message is shorter than required.
+	ErrPlaintextTooShort = errors.New("plaintext too short")
+)
+
+// MaxMessageSize is the maximum size in bytes of a plaintext accepted by EncryptSafe and DecryptSafe, and of a
+// single chunk of a stream. It is a guardrail against accidentally huge allocations, not a security boundary:
+// Encrypt and Decrypt don't enforce it.
+var MaxMessageSize = 64 * 1024 * 1024
+
+// maxMessageOverhead is the largest number of bytes a message adds to its plaintext.
+const maxMessageOverhead = HeaderSize + KeySize + NonceSize + box.Overhead
+
+// randReader is the source of randomness for key generation, nonces and data keys.
+var randReader io.Reader = rand.Reader
+
+// SetRandReader replaces the source of randomness used by the package, which defaults to crypto/rand.Reader. Passing
+// nil restores the default. This is intended for tests: in production the reader must be a cryptographically secure
+// random number generator. SetRandReader must not be called concurrently with other functions of the package.
+func SetRandReader(r io.Reader) {
+	if r == nil {
+		r = rand.Reader
+	}
+	randReader = r
+}
+
 type (
 	// PrivateKey is a private encryption key
 	PrivateKey [KeySize * 2]byte
@@ -24,9 +75,106 @@ type (
 
 // Generate generates a new PrivateKey.
 func Generate() (PrivateKey, error) {
+	return GenerateWithReader(randReader)
+}
+
+// GenerateContext generates a new PrivateKey, giving up if the context is done before enough randomness is
+// available. Generation itself can't be interrupted, so it may continue in the background after returning.
+func GenerateContext(ctx context.Context) (PrivateKey, error) {
+	if err := ctx.Err(); err != nil {
+		return PrivateKey{}, err
+	}
+
+	type result struct {
+		key PrivateKey
+		err error
+	}
+	c := make(chan result, 1)
+	go func() {
+		key, err := Generate()
+		c <- result{key, err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return PrivateKey{}, ctx.Err()
+	case r := <-c:
+		return r.key, r.err
+	}
+}
+
+// GenerateN generates n new PrivateKeys, spreading the work across GOMAXPROCS goroutines. If the context is done
+// before all keys are generated, the keys generated so far are returned along with the context's error.
+func GenerateN(ctx context.Context, n int) ([]PrivateKey, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("invalid number of keys %d", n)
+	}
+
+	workers := runtime.GOMAXPROCS(0)
+	if workers > n {
+		workers = n
+	}
+
+	keys := make([]PrivateKey, n)
+	generated := make([]bool, n)
+
+	var mu sync.Mutex
+	var next int
+	var firstErr error
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = ctx.Err()
+				}
+				if firstErr != nil || next == n {
+					mu.Unlock()
+					return
+				}
+				i := next
+				next++
+				mu.Unlock()
+
+				key, err := Generate()
+
+				mu.Lock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = err
+					}
+				} else {
+					keys[i] = key
+					generated[i] = true
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if firstErr == nil {
+		return keys, nil
+	}
+
+	partial := keys[:0]
+	for i, key := range keys {
+		if generated[i] {
+			partial = append(partial, key)
+		}
+	}
+	return partial, firstErr
+}
+
+// GenerateWithReader generates a new PrivateKey using randomness from r.
+func GenerateWithReader(r io.Reader) (PrivateKey, error) {
 	var key PrivateKey
 
-	pub, priv, err := box.GenerateKey(rand.Reader)
+	pub, priv, err := box.GenerateKey(r)
 	if err != nil {
 		return key, err
 	}
@@ -36,64 +184,373 @@ func Generate() (PrivateKey, error) {
 	return key, nil
 }
 
-// NewKey creates a new key from a base58 string.
+// GenerateFromReaderExact generates a new PrivateKey from exactly 32 bytes read from r. The bytes are clamped and
+// used as the private scalar. No more than 32 bytes are ever read, so the entropy consumed can be accounted for
+// precisely.
+func GenerateFromReaderExact(r io.Reader) (PrivateKey, error) {
+	var scalar [KeySize]byte
+	if _, err := io.ReadFull(r, scalar[:]); err != nil {
+		return PrivateKey{}, err
+	}
+	scalar[0] &= 248
+	scalar[31] &= 127
+	scalar[31] |= 64
+	return newPrivateKeyFromScalar(scalar[:])
+}
+
+// GenerateFromSeed deterministically generates a PrivateKey from a seed. The private scalar is the clamped first
+// half of the SHA-512 hash of the seed. The seed must be at least 32 bytes.
+func GenerateFromSeed(seed []byte) (PrivateKey, error) {
+	if len(seed) < KeySize {
+		return PrivateKey{}, fmt.Errorf("invalid seed: expected at least %d bytes, got %d", KeySize, len(seed))
+	}
+
+	digest := sha512.Sum512(seed)
+	scalar := digest[:KeySize]
+	scalar[0] &= 248
+	scalar[31] &= 127
+	scalar[31] |= 64
+	return newPrivateKeyFromScalar(scalar)
+}
+
+// newPrivateKeyFromScalar creates a new key from the private scalar by computing the matching public key.
+func newPrivateKeyFromScalar(scalar []byte) (key PrivateKey, err error) {
+	pub, err := curve25519.X25519(scalar, curve25519.Basepoint)
+	if err != nil {
+		return key, err
+	}
+	copy(key[:], scalar)
+	copy(key[KeySize:], pub)
+	return key, nil
+}
+
+// DerivePublicKey computes the public key for a raw 32 byte private scalar, as stored by other X25519 tooling. The
+// scalar is clamped before it is multiplied by the base point.
+func DerivePublicKey(privateScalar []byte) (PublicKey, error) {
+	var pub PublicKey
+	if len(privateScalar) != KeySize {
+		return pub, fmt.Errorf("%w: got %d bytes, want %d", ErrInvalidKeyLength, len(privateScalar), KeySize)
+	}
+
+	var scalar [KeySize]byte
+	copy(scalar[:], privateScalar)
+	scalar[0] &= 248
+	scalar[31] &= 127
+	scalar[31] |= 64
+
+	bs, err := curve25519.X25519(scalar[:], curve25519.Basepoint)
+	if err != nil {
+		return pub, err
+	}
+	copy(pub[:], bs)
+	return pub, nil
+}
+
+// NewPrivateKey creates a new key from a base58 string. Both the full 64 byte form returned by String and
+// the 32 byte private-only form are accepted. For the latter the public key is recomputed. Surrounding whitespace,
+// such as a trailing newline, is ignored.
 func NewPrivateKey(str string) (key PrivateKey, err error) {
-	bs, err := base58.Decode(str)
+	bs, err := base58.Decode(strings.TrimSpace(str))
+	if err != nil {
+		return key, fmt.Errorf("invalid key: %w", err)
+	}
+	return parsePrivateKey(bs)
+}
+
+// parsePrivateKey creates a new key from either its 64 byte or its 32 byte private-only form.
+func parsePrivateKey(bs []byte) (key PrivateKey, err error) {
+	switch len(bs) {
+	case KeySize * 2:
+		return NewPrivateKeyFromBytes(bs)
+	case KeySize:
+		return newPrivateKeyFromScalar(bs)
+	default:
+		return key, fmt.Errorf("%w: got %d bytes, want %d or %d", ErrInvalidKeyLength, len(bs), KeySize*2, KeySize)
+	}
+}
+
+// NewPrivateKeyFromBytes creates a new key from its raw 64 byte form (the private key followed by the public key).
+func NewPrivateKeyFromBytes(b []byte) (key PrivateKey, err error) {
+	if len(b) != KeySize*2 {
+		return key, fmt.Errorf("%w: got %d bytes, want %d", ErrInvalidKeyLength, len(b), KeySize*2)
+	}
+
+	pub, err := curve25519.X25519(b[:KeySize], curve25519.Basepoint)
 	if err != nil {
 		return key, err
 	}
-	if len(bs) != KeySize {
-		return key, errors.New("invalid key")
+	if subtle.ConstantTimeCompare(pub, b[KeySize:]) != 1 {
+		return key, ErrKeyMismatch
 	}
-	copy(key[:], bs)
+
+	copy(key[:], b)
 	return key, nil
 }
 
 // Decrypt decrypts data that was encrypted via a private key. The peer's public key is sent along with the data.
+//
+// Messages without a header, as produced by earlier versions of Encrypt, are decrypted as well. Messages whose sender
+// key is a low-order point are rejected with ErrLowOrderPoint before opening.
 func (key PrivateKey) Decrypt(data []byte) (PublicKey, []byte, error) {
-	var priv [KeySize]byte
-	copy(priv[:], key[:])
+	return key.DecryptAppend(nil, data)
+}
 
-	if len(data) < KeySize {
-		return PublicKey{}, nil, fmt.Errorf("invalid message: expected public key")
+// DecryptFrom is like Decrypt, but only accepts messages sent by expectedSender. ErrUnexpectedSender is returned for
+// messages from anyone else. The comparison is constant time.
+func (key PrivateKey) DecryptFrom(expectedSender PublicKey, data []byte) ([]byte, error) {
+	sender, opened, err := key.Decrypt(data)
+	if err != nil {
+		return nil, err
 	}
+	if !sender.Equal(expectedSender) {
+		return nil, decryptFailed(fmt.Errorf("invalid message: %w", ErrUnexpectedSender))
+	}
+	return opened, nil
+}
 
-	var pub [KeySize]byte
-	copy(pub[:], data[:])
-	data = data[KeySize:]
+// DecryptMin is like Decrypt, but returns ErrPlaintextTooShort for messages with a plaintext shorter than
+// minPlaintext bytes. The length is only checked once the message has been authenticated, so forged messages fail
+// the same way regardless of their length.
+func (key PrivateKey) DecryptMin(data []byte, minPlaintext int) (PublicKey, []byte, error) {
+	sender, opened, err := key.Decrypt(data)
+	if err != nil {
+		return sender, nil, err
+	}
+	if len(opened) < minPlaintext {
+		return sender, nil, decryptFailed(fmt.Errorf("invalid message: %w: got %d bytes, want at least %d", ErrPlaintextTooShort, len(opened), minPlaintext))
+	}
+	return sender, opened, nil
+}
 
-	if len(data) < NonceSize {
-		return pub, nil, fmt.Errorf("invalid message: expected nonce")
+// DecryptSafe is like Decrypt, but returns ErrMessageTooLarge rather than decrypting messages with a plaintext
+// larger than MaxMessageSize.
+func (key PrivateKey) DecryptSafe(data []byte) (PublicKey, []byte, error) {
+	if len(data) > MaxMessageSize+maxMessageOverhead {
+		return PublicKey{}, nil, decryptFailed(fmt.Errorf("invalid message: %w", ErrMessageTooLarge))
 	}
+	return key.Decrypt(data)
+}
 
-	var nonce [NonceSize]byte
-	copy(nonce[:], data[:])
-	data = data[NonceSize:]
+// DecryptAppend is like Decrypt, but appends the plaintext to dst and returns the resulting slice. If dst has enough
+// capacity no allocation is needed.
+func (key PrivateKey) DecryptAppend(dst, data []byte) (PublicKey, []byte, error) {
+	pub, opened, err := key.decryptAppend(dst, data)
+	if err != nil {
+		notifyDecryptFailure(err)
+	}
+	return pub, opened, err
+}
 
-	opened, ok := box.Open(nil, data, &nonce, &pub, &priv)
+func (key PrivateKey) decryptAppend(dst, data []byte) (PublicKey, []byte, error) {
+	typ, body, ok := parseHeader(data)
 	if !ok {
-		return pub, nil, fmt.Errorf("invalid message: nacl box open failed")
+		return key.decryptBox(dst, MessageTypeBox, data)
 	}
 
+	var pub PublicKey
+	var opened []byte
+	var err error
+	switch typ {
+	case MessageTypeBox:
+		pub, opened, err = key.decryptBox(dst, MessageTypeBox, body)
+	case MessageTypeCompressed:
+		pub, opened, err = key.decryptCompressed(dst, body)
+	case MessageTypePadded:
+		pub, opened, err = key.decryptPadded(dst, body)
+	case MessageTypeTimestamped:
+		// decrypting would skip the age check
+		err = errors.New("invalid message: timestamped messages must be decrypted via DecryptTimestamped")
+	default:
+		err = fmt.Errorf("invalid message: %w: %d", ErrUnknownVersion, typ)
+	}
+	if err != nil {
+		// a legacy message may start with the magic bytes by chance, but it is only tried if the header couldn't
+		// be parsed: a message that failed to authenticate is not opened a second time
+		if isHeaderParseError(err) {
+			if legacyPub, legacyOpened, legacyErr := key.decryptBox(dst, MessageTypeBox, data); legacyErr == nil {
+				return legacyPub, legacyOpened, nil
+			}
+		}
+		return pub, nil, err
+	}
 	return pub, opened, nil
 }
 
-// Encrypt encrypts data using the private key intended for the peer public key.
+// isHeaderParseError reports whether decrypting a message with a header failed because the header declared an
+// unknown type or the body was too short for it.
+func isHeaderParseError(err error) bool {
+	return errors.Is(err, ErrUnknownVersion) || errors.Is(err, ErrShortMessage) || errors.Is(err, ErrMissingNonce)
+}
+
+// DecryptPeekSender returns the sender's public key embedded in a message produced by Encrypt or one of its variants,
+// such as EncryptCompressed, without decrypting it, for example to route the message. The key isn't authenticated
+// until the message is decrypted. Messages without a header are read in the legacy format.
+func DecryptPeekSender(data []byte) (PublicKey, error) {
+	body := data
+	if typ, b, ok := parseHeader(data); ok {
+		switch typ {
+		case MessageTypeBox, MessageTypeCompressed, MessageTypePadded, MessageTypeTimestamped:
+			body = b
+		default:
+			return PublicKey{}, fmt.Errorf("invalid message: %w: %d", ErrUnknownVersion, typ)
+		}
+	}
+
+	var pub PublicKey
+	if len(body) < KeySize {
+		return pub, fmt.Errorf("invalid message: expected public key: %w", ErrShortMessage)
+	}
+	copy(pub[:], body)
+	return pub, nil
+}
+
+// PlaintextLen returns the length of the plaintext of a message produced by Encrypt, for example to size the buffer
+// passed to DecryptAppend. A sealed box has a fixed overhead, so the length follows from the length of the message
+// and no decryption is needed. The length isn't authenticated until the message is decrypted, but a message whose
+// length was tampered with fails to decrypt. For timestamped messages the length of the data returned by
+// DecryptTimestamped is returned. The length of compressed and padded messages can't be determined without
+// decrypting them.
+func PlaintextLen(data []byte) (int, error) {
+	body := data
+	prefix := 0
+	if typ, b, ok := parseHeader(data); ok {
+		switch typ {
+		case MessageTypeBox:
+			body = b
+		case MessageTypeTimestamped:
+			body = b
+			prefix = timestampSize
+		case MessageTypeCompressed, MessageTypePadded:
+			return 0, errors.New("invalid message: plaintext length of compressed or padded messages is unknown")
+		default:
+			return 0, fmt.Errorf("invalid message: %w: %d", ErrUnknownVersion, typ)
+		}
+	}
+
+	if len(body) < KeySize+NonceSize+box.Overhead+prefix {
+		return 0, fmt.Errorf("invalid message: %w", ErrShortMessage)
+	}
+	return len(body) - KeySize - NonceSize - box.Overhead - prefix, nil
+}
+
+// decryptBox decrypts the body of a message of the type: the peer's public key, the nonce and the sealed box.
+// Messages without a header are of MessageTypeBox. The plaintext is appended to dst.
+func (key PrivateKey) decryptBox(dst []byte, typ byte, data []byte) (PublicKey, []byte, error) {
+	msg, err := parseBox(data)
+	if err != nil {
+		return msg.SenderPublicKey, nil, err
+	}
+	if err := msg.SenderPublicKey.Validate(); err != nil {
+		return msg.SenderPublicKey, nil, fmt.Errorf("invalid message: %w", err)
+	}
+
+	sealKey := messageKey(key.Precompute(msg.SenderPublicKey), typ)
+	opened, ok := box.OpenAfterPrecomputation(dst, msg.Ciphertext, msg.Nonce.ptr(), &sealKey)
+	if !ok {
+		return msg.SenderPublicKey, nil, fmt.Errorf("invalid message: %w", ErrOpenFailed)
+	}
+
+	return msg.SenderPublicKey, opened, nil
+}
+
+// Encrypt encrypts data using the private key intended for the peer public key. Encrypt panics if the source of
+// randomness fails, use EncryptSafe to handle such failures.
+//
+// The result is a header identifying the message type, the sender's public key, the nonce and the sealed box.
 func (key PrivateKey) Encrypt(peersPublicKey PublicKey, data []byte) []byte {
-	var priv [KeySize]byte
-	copy(priv[:], key[:KeySize])
+	result := make([]byte, 0, HeaderSize+KeySize+NonceSize+len(data)+box.Overhead)
+	return key.EncryptAppend(result, peersPublicKey, data)
+}
+
+// EncryptSafe is like Encrypt, but returns an error rather than panicking if the source of randomness fails, and
+// returns ErrMessageTooLarge if data is larger than MaxMessageSize.
+func (key PrivateKey) EncryptSafe(peersPublicKey PublicKey, data []byte) ([]byte, error) {
+	if len(data) > MaxMessageSize {
+		return nil, ErrMessageTooLarge
+	}
+
+	nonce, err := generateNonce()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]byte, 0, HeaderSize+KeySize+NonceSize+len(data)+box.Overhead)
+	return key.encryptAppend(result, peersPublicKey, nonce, MessageTypeBox, data), nil
+}
+
+// EncryptAppend is like Encrypt, but appends the message to dst and returns the resulting slice. If dst has enough
+// capacity no allocation is needed.
+func (key PrivateKey) EncryptAppend(dst []byte, peersPublicKey PublicKey, data []byte) []byte {
+	return key.encryptAppend(dst, peersPublicKey, mustGenerateNonce(), MessageTypeBox, data)
+}
+
+// EncryptWithNonce is like Encrypt, but uses the supplied nonce rather than a random one.
+//
+// WARNING: a nonce must never be used twice with the same pair of keys. Reusing a nonce reveals the XOR of the
+// plaintexts and allows an attacker to forge messages. Only use this with a scheme that guarantees uniqueness,
+// such as a monotonic counter that is never reset.
+func (key PrivateKey) EncryptWithNonce(peersPublicKey PublicKey, nonce Nonce, data []byte) []byte {
+	result := make([]byte, 0, HeaderSize+KeySize+NonceSize+len(data)+box.Overhead)
+	return key.encryptAppend(result, peersPublicKey, nonce, MessageTypeBox, data)
+}
+
+// EncryptWithNonceOut is like Encrypt, but also returns the random nonce the message was sealed with, for example to
+// record it in an audit log.
+func (key PrivateKey) EncryptWithNonceOut(peersPublicKey PublicKey, data []byte) ([]byte, Nonce) {
+	nonce := mustGenerateNonce()
+	return key.EncryptWithNonce(peersPublicKey, nonce, data), nonce
+}
+
+func (key PrivateKey) encryptAppend(dst []byte, peersPublicKey PublicKey, nonce Nonce, typ byte, data []byte) []byte {
+	sealKey := messageKey(key.Precompute(peersPublicKey), typ)
+
+	dst = appendHeader(dst, typ)
+	dst = append(dst, key[KeySize:]...)
+	dst = append(dst, nonce[:]...)
+	return box.SealAfterPrecomputation(dst, data, nonce.ptr(), &sealKey)
+}
+
+// Encryptv is like Encrypt, but seals the concatenation of the segments without the caller having to join them
+// first. The segments are assembled in the spare capacity of the result, so a single allocation is made for the
+// whole message.
+func (key PrivateKey) Encryptv(peersPublicKey PublicKey, segments ...[]byte) []byte {
+	n := 0
+	for _, segment := range segments {
+		n += len(segment)
+	}
 
-	var pub [KeySize]byte
-	copy(pub[:], peersPublicKey[:])
+	sealedSize := HeaderSize + KeySize + NonceSize + n + box.Overhead
+	result := make([]byte, 0, sealedSize+n)
 
-	nonce := generateNonce()
-	sealed := box.Seal(nil, data, &nonce, &pub, &priv)
+	// box.Seal doesn't allow its input to overlap the output, so the plaintext is assembled after the sealed box and
+	// wiped once it has been sealed.
+	data := result[sealedSize:sealedSize]
+	for _, segment := range segments {
+		data = append(data, segment...)
+	}
+	result = key.encryptAppend(result, peersPublicKey, mustGenerateNonce(), MessageTypeBox, data)
+	for i := range data {
+		data[i] = 0
+	}
+	return result[:len(result):len(result)]
+}
 
-	result := make([]byte, 0, len(pub)+len(nonce)+len(sealed))
-	result = append(result, key[KeySize:]...)
-	result = append(result, nonce[:]...)
-	result = append(result, sealed...)
-	return result
+// EncryptString encrypts the string for the peer public key and returns the message base58 encoded.
+func (key PrivateKey) EncryptString(peersPublicKey PublicKey, s string) string {
+	return base58.Encode(key.Encrypt(peersPublicKey, []byte(s)))
+}
+
+// DecryptString decrypts a base58 encoded message produced by EncryptString.
+func (key PrivateKey) DecryptString(s string) (PublicKey, string, error) {
+	data, err := base58.Decode(s)
+	if err != nil {
+		return PublicKey{}, "", decryptFailed(fmt.Errorf("invalid message: %w", err))
+	}
+
+	pub, opened, err := key.Decrypt(data)
+	if err != nil {
+		return pub, "", err
+	}
+	return pub, string(opened), nil
 }
 
 func (key PrivateKey) PublicKey() PublicKey {
@@ -102,24 +559,153 @@ func (key PrivateKey) PublicKey() PublicKey {
 	return pub
 }
 
+// Equal reports whether the two private keys are equal. The comparison is constant time.
+func (key PrivateKey) Equal(other PrivateKey) bool {
+	return subtle.ConstantTimeCompare(key[:], other[:]) == 1
+}
+
+// IsZero reports whether the private key is the zero value, i.e. not set. The check is constant time.
+func (key PrivateKey) IsZero() bool {
+	var zero PrivateKey
+	return subtle.ConstantTimeCompare(key[:], zero[:]) == 1
+}
+
+// Clone returns a copy of the private key. Keys are arrays and are copied on assignment anyway, Clone makes such a
+// copy explicit, for example before calling Zero on the original.
+func (key PrivateKey) Clone() PrivateKey {
+	var clone PrivateKey
+	copy(clone[:], key[:])
+	return clone
+}
+
+// Zero overwrites the private key with zeros. After calling Zero the key is unusable.
+func (key *PrivateKey) Zero() {
+	for i := range key {
+		key[i] = 0
+	}
+	// make sure the writes aren't optimized away
+	runtime.KeepAlive(key)
+}
+
+// Bytes returns a copy of the raw 64 bytes of the key (the private key followed by the public key).
+func (key PrivateKey) Bytes() []byte {
+	return append([]byte(nil), key[:]...)
+}
+
+// PublicBytes returns a copy of the raw 32 bytes of the public half of the key.
+func (key PrivateKey) PublicBytes() []byte {
+	return append([]byte(nil), key[KeySize:]...)
+}
+
 // String returns the base58 encoded representation of the private key.
 func (key PrivateKey) String() string {
 	return base58.Encode(key[:])
 }
 
+// MarshalJSON marshals the key as a base58 JSON string.
+func (key PrivateKey) MarshalJSON() ([]byte, error) {
+	return json.Marshal(key.String())
+}
+
+// UnmarshalJSON unmarshals the key from a base58 JSON string.
+func (key *PrivateKey) UnmarshalJSON(data []byte) error {
+	var str string
+	err := json.Unmarshal(data, &str)
+	if err != nil {
+		return err
+	}
+	if str == "" {
+		return errors.New("invalid key: empty string")
+	}
+	*key, err = NewPrivateKey(str)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (key PrivateKey) MarshalText() ([]byte, error) {
+	return []byte(key.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (key *PrivateKey) UnmarshalText(text []byte) error {
+	var err error
+	*key, err = NewPrivateKey(string(text))
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler. The raw 64 bytes of the key are returned.
+func (key PrivateKey) MarshalBinary() ([]byte, error) {
+	return append([]byte(nil), key[:]...), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. Like NewPrivateKeyFromBytes the public half must match
+// the private half.
+func (key *PrivateKey) UnmarshalBinary(data []byte) error {
+	decoded, err := NewPrivateKeyFromBytes(data)
+	if err != nil {
+		return err
+	}
+	*key = decoded
+	return nil
+}
+
+// WriteTo implements io.WriterTo. The raw 64 bytes of the key are written.
+func (key PrivateKey) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write(key[:])
+	return int64(n), err
+}
+
+// ReadFrom implements io.ReaderFrom. Exactly 64 bytes are read and, like NewPrivateKeyFromBytes, the public half
+// must match the private half.
+func (key *PrivateKey) ReadFrom(r io.Reader) (int64, error) {
+	var buf [KeySize * 2]byte
+	n, err := io.ReadFull(r, buf[:])
+	if err != nil {
+		return int64(n), err
+	}
+	decoded, err := NewPrivateKeyFromBytes(buf[:])
+	if err != nil {
+		return int64(n), err
+	}
+	*key = decoded
+	return int64(n), nil
+}
+
 // MarshalYAML marshales the key for use in a YAML file.
 func (key PrivateKey) MarshalYAML() (interface{}, error) {
 	return key.String(), nil
 }
 
-// UnmarshalYAML unmarshales the key from a YAML file.
+// UnmarshalYAML unmarshales the key from a YAML file. Both gopkg.in/yaml.v2 and gopkg.in/yaml.v3 support this
+// signature.
+//
+// Unlike NewPrivateKey only the full 64 byte form written by MarshalYAML is accepted. A 32 byte value is far more
+// likely to be a public key put where a private key was expected than a private-only key, so it is rejected with
+// ErrInvalidKeyLength rather than silently turned into a private key nobody holds the public half of.
+//
+// This is a breaking change for YAML files holding the 32 byte form, which was the only form earlier versions
+// accepted. Such values must be rewritten in the 64 byte form, for example via NewPrivateKey and String. The other
+// decoders, UnmarshalJSON, UnmarshalText and the KeyStore implementations, still accept both forms.
 func (key *PrivateKey) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	var str string
 	err := unmarshal(&str)
 	if err != nil {
 		return err
 	}
-	*key, err = NewPrivateKey(str)
+	bs, err := base58.Decode(strings.TrimSpace(str))
+	if err != nil {
+		return fmt.Errorf("invalid key: %w", err)
+	}
+	if len(bs) == KeySize {
+		return fmt.Errorf("%w: got %d bytes, want %d: the value looks like a public key", ErrInvalidKeyLength, len(bs), KeySize*2)
+	}
+	*key, err = parsePrivateKey(bs)
 	if err != nil {
 		return err
 	}
@@ -131,30 +717,149 @@ type (
 	PublicKey [KeySize]byte
 )
 
-// NewKey creates a new key from a base58 string.
+// NewPublicKey creates a new key from a base58 string. Surrounding whitespace is ignored.
 func NewPublicKey(str string) (key PublicKey, err error) {
-	bs, err := base58.Decode(str)
+	bs, err := base58.Decode(strings.TrimSpace(str))
 	if err != nil {
-		return key, err
+		return key, fmt.Errorf("invalid key: %w", err)
 	}
+	return parsePublicKey(bs)
+}
+
+// parsePublicKey creates a new key from its 32 byte form.
+func parsePublicKey(bs []byte) (key PublicKey, err error) {
 	if len(bs) != KeySize {
-		return key, errors.New("invalid key")
+		return key, fmt.Errorf("%w: got %d bytes, want %d", ErrInvalidKeyLength, len(bs), KeySize)
 	}
 	copy(key[:], bs)
 	return key, nil
 }
 
+// Equal reports whether the two public keys are equal. The comparison is constant time.
+func (key PublicKey) Equal(other PublicKey) bool {
+	return subtle.ConstantTimeCompare(key[:], other[:]) == 1
+}
+
+// IsZero reports whether the public key is the zero value, i.e. not set. The check is constant time.
+func (key PublicKey) IsZero() bool {
+	var zero PublicKey
+	return subtle.ConstantTimeCompare(key[:], zero[:]) == 1
+}
+
+// Fingerprint returns a short fingerprint of the public key for display: the first 8 bytes of its SHA-256 hash
+// as colon separated hex.
+func (key PublicKey) Fingerprint() string {
+	id := key.ID()
+	var sb strings.Builder
+	for i, b := range id {
+		if i > 0 {
+			sb.WriteByte(':')
+		}
+		fmt.Fprintf(&sb, "%02x", b)
+	}
+	return sb.String()
+}
+
+// ID returns a fixed-width identifier of the public key for use in storage keys and indexes: the first 8 bytes of
+// its SHA-256 hash. These are the same bytes Fingerprint displays.
+func (key PublicKey) ID() [8]byte {
+	digest := sha256.Sum256(key[:])
+	var id [8]byte
+	copy(id[:], digest[:])
+	return id
+}
+
+// IDString returns the base58 encoded ID of the public key.
+func (key PublicKey) IDString() string {
+	id := key.ID()
+	return base58.Encode(id[:])
+}
+
+// Bytes returns a copy of the raw 32 bytes of the public key.
+func (key PublicKey) Bytes() []byte {
+	return append([]byte(nil), key[:]...)
+}
+
 // String returns the base58 encoded public key.
 func (key PublicKey) String() string {
 	return base58.Encode(key[:])
 }
 
+// MarshalJSON marshals the public key as a base58 JSON string.
+func (key PublicKey) MarshalJSON() ([]byte, error) {
+	return json.Marshal(key.String())
+}
+
+// UnmarshalJSON unmarshals the public key from a base58 JSON string.
+func (key *PublicKey) UnmarshalJSON(data []byte) error {
+	var str string
+	err := json.Unmarshal(data, &str)
+	if err != nil {
+		return err
+	}
+	if str == "" {
+		return errors.New("invalid key: empty string")
+	}
+	*key, err = NewPublicKey(str)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (key PublicKey) MarshalText() ([]byte, error) {
+	return []byte(key.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (key *PublicKey) UnmarshalText(text []byte) error {
+	var err error
+	*key, err = NewPublicKey(string(text))
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler. The raw 32 bytes of the key are returned.
+func (key PublicKey) MarshalBinary() ([]byte, error) {
+	return append([]byte(nil), key[:]...), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (key *PublicKey) UnmarshalBinary(data []byte) error {
+	if len(data) != KeySize {
+		return fmt.Errorf("%w: got %d bytes, want %d", ErrInvalidKeyLength, len(data), KeySize)
+	}
+	copy(key[:], data)
+	return nil
+}
+
+// WriteTo implements io.WriterTo. The raw 32 bytes of the key are written.
+func (key PublicKey) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write(key[:])
+	return int64(n), err
+}
+
+// ReadFrom implements io.ReaderFrom. Exactly 32 bytes are read.
+func (key *PublicKey) ReadFrom(r io.Reader) (int64, error) {
+	var buf PublicKey
+	n, err := io.ReadFull(r, buf[:])
+	if err != nil {
+		return int64(n), err
+	}
+	*key = buf
+	return int64(n), nil
+}
+
 // MarshalYAML marshals the public key for a YAML file.
 func (key PublicKey) MarshalYAML() (interface{}, error) {
 	return key.String(), nil
 }
 
-// UnmarshalYAML unmarshals the public key from a YAML file.
+// UnmarshalYAML unmarshals the public key from a YAML file. Both gopkg.in/yaml.v2 and gopkg.in/yaml.v3 support
+// this signature.
 func (key *PublicKey) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	var str string
 	err := unmarshal(&str)
@@ -168,14 +873,56 @@ func (key *PublicKey) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	return nil
 }
 
-type (
-	// Nonce is a number used once.
-	Nonce = [NonceSize]byte
-)
+// Nonce is a number used once.
+type Nonce [NonceSize]byte
+
+// NewNonce converts a byte array to a Nonce.
+func NewNonce(bs [NonceSize]byte) Nonce {
+	return Nonce(bs)
+}
+
+// ParseNonce parses a base58 encoded nonce, as returned by String.
+func ParseNonce(str string) (Nonce, error) {
+	var nonce Nonce
+	bs, err := base58.Decode(strings.TrimSpace(str))
+	if err != nil {
+		return nonce, fmt.Errorf("invalid nonce: %w", err)
+	}
+	if len(bs) != NonceSize {
+		return nonce, fmt.Errorf("invalid nonce: got %d bytes, want %d", len(bs), NonceSize)
+	}
+	copy(nonce[:], bs)
+	return nonce, nil
+}
+
+// String returns the base58 encoded nonce.
+func (nonce Nonce) String() string {
+	return base58.Encode(nonce[:])
+}
+
+// Array returns the nonce as a byte array.
+func (nonce Nonce) Array() [NonceSize]byte {
+	return nonce
+}
+
+// ptr returns a pointer to the nonce as a byte array, as expected by the nacl packages.
+func (nonce *Nonce) ptr() *[NonceSize]byte {
+	return (*[NonceSize]byte)(nonce)
+}
 
-func generateNonce() Nonce {
+// generateNonce generates a random nonce.
+func generateNonce() (Nonce, error) {
 	var nonce Nonce
-	if _, err := io.ReadFull(rand.Reader, nonce[:]); err != nil {
+	if _, err := io.ReadFull(randReader, nonce[:]); err != nil {
+		return nonce, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return nonce, nil
+}
+
+// mustGenerateNonce generates a random nonce, panicking if the source of randomness fails.
+func mustGenerateNonce() Nonce {
+	nonce, err := generateNonce()
+	if err != nil {
 		panic(err)
 	}
 	return nonce
@@ -1,8 +1,21 @@
 package crypt
 
 import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	mathrand "math/rand"
+	"sync/atomic"
 	"testing"
+	"testing/iotest"
 
+	"github.com/mr-tron/base58"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -22,3 +35,959 @@ func Test(t *testing.T) {
 		assert.Equal(t, msg, decrypted)
 	}
 }
+
+func TestJSON(t *testing.T) {
+	priv, err := Generate()
+	assert.NoError(t, err)
+	pub := priv.PublicKey()
+
+	t.Run("PrivateKey", func(t *testing.T) {
+		bs, err := json.Marshal(priv)
+		assert.NoError(t, err)
+		assert.Equal(t, `"`+priv.String()+`"`, string(bs))
+
+		var decoded PrivateKey
+		err = json.Unmarshal(bs, &decoded)
+		if assert.NoError(t, err) {
+			assert.Equal(t, priv, decoded)
+		}
+	})
+	t.Run("PublicKey", func(t *testing.T) {
+		bs, err := json.Marshal(pub)
+		assert.NoError(t, err)
+		assert.Equal(t, `"`+pub.String()+`"`, string(bs))
+
+		var decoded PublicKey
+		err = json.Unmarshal(bs, &decoded)
+		if assert.NoError(t, err) {
+			assert.Equal(t, pub, decoded)
+		}
+	})
+	t.Run("Struct", func(t *testing.T) {
+		type config struct {
+			Private PrivateKey `json:"private"`
+			Public  PublicKey  `json:"public"`
+		}
+		bs, err := json.Marshal(config{Private: priv, Public: pub})
+		assert.NoError(t, err)
+
+		var decoded config
+		err = json.Unmarshal(bs, &decoded)
+		if assert.NoError(t, err) {
+			assert.Equal(t, priv, decoded.Private)
+			assert.Equal(t, pub, decoded.Public)
+		}
+	})
+	t.Run("Empty", func(t *testing.T) {
+		var decodedPriv PrivateKey
+		assert.Error(t, json.Unmarshal([]byte(`""`), &decodedPriv))
+		var decodedPub PublicKey
+		assert.Error(t, json.Unmarshal([]byte(`""`), &decodedPub))
+	})
+}
+
+func TestText(t *testing.T) {
+	priv, err := Generate()
+	assert.NoError(t, err)
+	pub := priv.PublicKey()
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		text, err := priv.MarshalText()
+		assert.NoError(t, err)
+		var decodedPriv PrivateKey
+		if assert.NoError(t, decodedPriv.UnmarshalText(text)) {
+			assert.Equal(t, priv, decodedPriv)
+		}
+
+		text, err = pub.MarshalText()
+		assert.NoError(t, err)
+		var decodedPub PublicKey
+		if assert.NoError(t, decodedPub.UnmarshalText(text)) {
+			assert.Equal(t, pub, decodedPub)
+		}
+	})
+	t.Run("MapKey", func(t *testing.T) {
+		m := map[PublicKey]string{pub: "peer"}
+		bs, err := json.Marshal(m)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"`+pub.String()+`":"peer"}`, string(bs))
+
+		var decoded map[PublicKey]string
+		if assert.NoError(t, json.Unmarshal(bs, &decoded)) {
+			assert.Equal(t, m, decoded)
+		}
+	})
+	t.Run("Invalid", func(t *testing.T) {
+		var decodedPub PublicKey
+		assert.EqualError(t, decodedPub.UnmarshalText([]byte("abc")), "invalid key: got 3 bytes, want 32")
+		var decodedPriv PrivateKey
+		assert.EqualError(t, decodedPriv.UnmarshalText([]byte("abc")), "invalid key: got 3 bytes, want 64 or 32")
+		assert.Error(t, decodedPub.UnmarshalText([]byte("0OIl")))
+	})
+}
+
+func TestBinary(t *testing.T) {
+	priv, err := Generate()
+	assert.NoError(t, err)
+	pub := priv.PublicKey()
+
+	t.Run("Gob", func(t *testing.T) {
+		type record struct {
+			Private PrivateKey
+			Public  PublicKey
+		}
+		var buf bytes.Buffer
+		err := gob.NewEncoder(&buf).Encode(record{Private: priv, Public: pub})
+		assert.NoError(t, err)
+
+		var decoded record
+		err = gob.NewDecoder(&buf).Decode(&decoded)
+		if assert.NoError(t, err) {
+			assert.Equal(t, priv, decoded.Private)
+			assert.Equal(t, pub, decoded.Public)
+		}
+	})
+	t.Run("InvalidLength", func(t *testing.T) {
+		var decodedPriv PrivateKey
+		err := decodedPriv.UnmarshalBinary(make([]byte, KeySize))
+		assert.True(t, errors.Is(err, ErrInvalidKeyLength))
+		assert.EqualError(t, err, "invalid key: got 32 bytes, want 64")
+		var decodedPub PublicKey
+		err = decodedPub.UnmarshalBinary(make([]byte, KeySize*2))
+		assert.True(t, errors.Is(err, ErrInvalidKeyLength))
+		assert.EqualError(t, err, "invalid key: got 64 bytes, want 32")
+	})
+	t.Run("KeyMismatch", func(t *testing.T) {
+		corrupted := priv
+		corrupted[KeySize] ^= 0xff
+		var decodedPriv PrivateKey
+		assert.Equal(t, ErrKeyMismatch, decodedPriv.UnmarshalBinary(corrupted[:]))
+		assert.Equal(t, PrivateKey{}, decodedPriv, "key should be unchanged")
+	})
+}
+
+func TestNewPrivateKeyFromBytes(t *testing.T) {
+	priv, err := Generate()
+	assert.NoError(t, err)
+
+	decoded, err := NewPrivateKeyFromBytes(priv[:])
+	if assert.NoError(t, err) {
+		assert.Equal(t, priv, decoded)
+	}
+
+	_, err = NewPrivateKeyFromBytes(priv[:KeySize])
+	assert.Error(t, err)
+
+	corrupted := priv
+	corrupted[KeySize] ^= 0xff
+	_, err = NewPrivateKeyFromBytes(corrupted[:])
+	assert.Equal(t, ErrKeyMismatch, err)
+}
+
+func TestNewPrivateKey(t *testing.T) {
+	priv, err := Generate()
+	assert.NoError(t, err)
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		decoded, err := NewPrivateKey(priv.String())
+		if assert.NoError(t, err) {
+			assert.Equal(t, priv, decoded)
+		}
+	})
+	t.Run("PrivateOnly", func(t *testing.T) {
+		decoded, err := NewPrivateKey(base58.Encode(priv[:KeySize]))
+		if assert.NoError(t, err) {
+			assert.Equal(t, priv, decoded)
+		}
+	})
+	t.Run("Whitespace", func(t *testing.T) {
+		for _, str := range []string{"  " + priv.String(), priv.String() + "\n", priv.String() + "\r\n", "\t" + priv.String() + " \n"} {
+			decoded, err := NewPrivateKey(str)
+			if assert.NoError(t, err, "%q", str) {
+				assert.Equal(t, priv, decoded)
+			}
+		}
+	})
+}
+
+func TestNewPublicKey(t *testing.T) {
+	priv, err := Generate()
+	assert.NoError(t, err)
+	pub := priv.PublicKey()
+
+	for _, str := range []string{pub.String(), "  " + pub.String(), pub.String() + "\n", pub.String() + "\r\n"} {
+		decoded, err := NewPublicKey(str)
+		if assert.NoError(t, err, "%q", str) {
+			assert.Equal(t, pub, decoded)
+		}
+	}
+
+	_, err = NewPublicKey(pub.String()[:10])
+	assert.Error(t, err)
+}
+
+func TestInvalidKeyLength(t *testing.T) {
+	priv, err := Generate()
+	assert.NoError(t, err)
+	pub := priv.PublicKey()
+
+	_, err = NewPublicKey(base58.Encode(pub[:KeySize-1]))
+	assert.True(t, errors.Is(err, ErrInvalidKeyLength))
+	assert.EqualError(t, err, "invalid key: got 31 bytes, want 32")
+
+	_, err = NewPrivateKey(base58.Encode(priv[:KeySize+1]))
+	assert.True(t, errors.Is(err, ErrInvalidKeyLength))
+	assert.EqualError(t, err, "invalid key: got 33 bytes, want 64 or 32")
+
+	_, err = NewPrivateKeyFromBytes(priv[:KeySize])
+	assert.True(t, errors.Is(err, ErrInvalidKeyLength))
+	assert.EqualError(t, err, "invalid key: got 32 bytes, want 64")
+
+	// other invalid keys don't have the wrong length
+	_, err = NewPublicKey("0OIl")
+	assert.False(t, errors.Is(err, ErrInvalidKeyLength))
+	_, err = NewPrivateKeyFromBytes(append(priv[:KeySize:KeySize], make([]byte, KeySize)...))
+	assert.False(t, errors.Is(err, ErrInvalidKeyLength))
+}
+
+func TestDecryptErrors(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	encrypted := k1.Encrypt(k2.PublicKey(), []byte("Hello World"))
+
+	_, _, err = k2.Decrypt(encrypted[:KeySize-1])
+	assert.True(t, errors.Is(err, ErrShortMessage))
+	assert.EqualError(t, err, "invalid message: expected public key: message too short")
+
+	_, _, err = k2.Decrypt(encrypted[:KeySize+NonceSize-1])
+	assert.True(t, errors.Is(err, ErrMissingNonce))
+
+	encrypted[len(encrypted)-1] ^= 0xff
+	_, _, err = k2.Decrypt(encrypted)
+	assert.True(t, errors.Is(err, ErrOpenFailed))
+	assert.False(t, errors.Is(err, ErrShortMessage))
+}
+
+func TestEqual(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	assert.True(t, k1.Equal(k1))
+	assert.False(t, k1.Equal(k2))
+	assert.True(t, k1.PublicKey().Equal(k1.PublicKey()))
+	assert.False(t, k1.PublicKey().Equal(k2.PublicKey()))
+
+	modified := k1
+	modified[len(modified)-1] ^= 0x01
+	assert.False(t, k1.Equal(modified))
+}
+
+func TestZero(t *testing.T) {
+	k, err := Generate()
+	assert.NoError(t, err)
+
+	k.Zero()
+	for i, b := range k {
+		assert.Equal(t, byte(0), b, "byte %d should be zero", i)
+	}
+}
+
+func TestGenerateFromSeed(t *testing.T) {
+	seed := []byte("0123456789abcdef0123456789abcdef")
+
+	k1, err := GenerateFromSeed(seed)
+	assert.NoError(t, err)
+	k2, err := GenerateFromSeed(seed)
+	assert.NoError(t, err)
+	assert.Equal(t, k1, k2)
+
+	// pin the derivation so it can't change between releases
+	assert.Equal(t, "6W5kPSASbxje1BjWjWbVGe7XvzHEJSuMUqtaWqAusHfs", k1.PublicKey().String())
+
+	other, err := GenerateFromSeed([]byte("0123456789abcdef0123456789abcdeF"))
+	assert.NoError(t, err)
+	assert.NotEqual(t, k1, other)
+
+	_, err = GenerateFromSeed(seed[:KeySize-1])
+	assert.Error(t, err)
+
+	// keys from a seed work like any other
+	msg := []byte("Hello World")
+	_, decrypted, err := k2.Decrypt(other.Encrypt(k2.PublicKey(), msg))
+	if assert.NoError(t, err) {
+		assert.Equal(t, msg, decrypted)
+	}
+}
+
+func TestGenerateWithReader(t *testing.T) {
+	seed := bytes.Repeat([]byte{0x42}, KeySize)
+
+	k1, err := GenerateWithReader(bytes.NewReader(seed))
+	assert.NoError(t, err)
+	k2, err := GenerateWithReader(bytes.NewReader(seed))
+	assert.NoError(t, err)
+	assert.Equal(t, k1, k2)
+
+	_, err = GenerateWithReader(bytes.NewReader(seed[:KeySize-1]))
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+}
+
+func TestFingerprint(t *testing.T) {
+	pub, err := NewPublicKey("6W5kPSASbxje1BjWjWbVGe7XvzHEJSuMUqtaWqAusHfs")
+	assert.NoError(t, err)
+	assert.Equal(t, "61:72:8a:ac:8a:a3:3d:03", pub.Fingerprint())
+}
+
+func TestID(t *testing.T) {
+	pub, err := NewPublicKey("6W5kPSASbxje1BjWjWbVGe7XvzHEJSuMUqtaWqAusHfs")
+	assert.NoError(t, err)
+	assert.Equal(t, [8]byte{0x61, 0x72, 0x8a, 0xac, 0x8a, 0xa3, 0x3d, 0x03}, pub.ID())
+	assert.Equal(t, pub.ID(), pub.ID())
+	assert.Equal(t, "HJN1Bk9gDs8", pub.IDString())
+
+	ids := make(map[[8]byte]bool)
+	for i := 0; i < 1000; i++ {
+		k, err := Generate()
+		assert.NoError(t, err)
+		ids[k.PublicKey().ID()] = true
+	}
+	assert.Len(t, ids, 1000)
+}
+
+func TestDecryptAppend(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	msg := []byte("Hello World")
+	encrypted := k1.Encrypt(k2.PublicKey(), msg)
+
+	buf := make([]byte, 0, 64)
+	buf = append(buf, "prefix:"...)
+	pub, decrypted, err := k2.DecryptAppend(buf, encrypted)
+	if assert.NoError(t, err) {
+		assert.Equal(t, k1.PublicKey(), pub)
+		assert.Equal(t, "prefix:Hello World", string(decrypted))
+		assert.Equal(t, &buf[:1][0], &decrypted[0], "expected dst to be reused")
+	}
+}
+
+func BenchmarkDecrypt(b *testing.B) {
+	k1, _ := Generate()
+	k2, _ := Generate()
+	encrypted := k1.Encrypt(k2.PublicKey(), make([]byte, 256))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _, _ = k2.Decrypt(encrypted)
+	}
+}
+
+func BenchmarkDecryptAppend(b *testing.B) {
+	k1, _ := Generate()
+	k2, _ := Generate()
+	encrypted := k1.Encrypt(k2.PublicKey(), make([]byte, 256))
+	buf := make([]byte, 0, 256)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _, _ = k2.DecryptAppend(buf[:0], encrypted)
+	}
+}
+
+func TestEncryptAppend(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	msg := []byte("Hello World")
+
+	buf := make([]byte, 0, 128)
+	buf = append(buf, "prefix:"...)
+	encrypted := k1.EncryptAppend(buf, k2.PublicKey(), msg)
+	assert.Equal(t, "prefix:", string(encrypted[:7]))
+	assert.Equal(t, &buf[:1][0], &encrypted[0], "expected dst to be reused")
+
+	pub, decrypted, err := k2.Decrypt(encrypted[7:])
+	if assert.NoError(t, err) {
+		assert.Equal(t, k1.PublicKey(), pub)
+		assert.Equal(t, msg, decrypted)
+	}
+}
+
+func BenchmarkEncrypt(b *testing.B) {
+	k1, _ := Generate()
+	k2, _ := Generate()
+	peer := k2.PublicKey()
+	msg := make([]byte, 256)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		k1.Encrypt(peer, msg)
+	}
+}
+
+func BenchmarkEncryptAppend(b *testing.B) {
+	k1, _ := Generate()
+	k2, _ := Generate()
+	peer := k2.PublicKey()
+	msg := make([]byte, 256)
+	buf := make([]byte, 0, 512)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		k1.EncryptAppend(buf[:0], peer, msg)
+	}
+}
+
+func TestEncryptWithNonce(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	msg := []byte("Hello World")
+
+	var nonce Nonce
+	binary.BigEndian.PutUint64(nonce[NonceSize-8:], 42)
+
+	e1 := k1.EncryptWithNonce(k2.PublicKey(), nonce, msg)
+	e2 := k1.EncryptWithNonce(k2.PublicKey(), nonce, msg)
+	assert.Equal(t, e1, e2)
+	assert.Equal(t, nonce[:], e1[HeaderSize+KeySize:HeaderSize+KeySize+NonceSize])
+
+	pub, decrypted, err := k2.Decrypt(e1)
+	if assert.NoError(t, err) {
+		assert.Equal(t, k1.PublicKey(), pub)
+		assert.Equal(t, msg, decrypted)
+	}
+}
+
+func TestGenerateContext(t *testing.T) {
+	k, err := GenerateContext(context.Background())
+	assert.NoError(t, err)
+	assert.NotEqual(t, PrivateKey{}, k)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = GenerateContext(ctx)
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestEncryptString(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	for _, msg := range []string{"", "Hello World", "héllo wörld", "こんにちは世界", "🔐🚇"} {
+		encrypted := k1.EncryptString(k2.PublicKey(), msg)
+		pub, decrypted, err := k2.DecryptString(encrypted)
+		if assert.NoError(t, err) {
+			assert.Equal(t, k1.PublicKey(), pub)
+			assert.Equal(t, msg, decrypted)
+		}
+	}
+
+	_, _, err = k2.DecryptString("not base58: 0OIl")
+	assert.Error(t, err)
+}
+
+func TestWriteToReadFrom(t *testing.T) {
+	priv, err := Generate()
+	assert.NoError(t, err)
+	pub := priv.PublicKey()
+
+	var buf bytes.Buffer
+	n, err := priv.WriteTo(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(KeySize*2), n)
+	n, err = pub.WriteTo(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(KeySize), n)
+	stream := buf.Bytes()
+
+	t.Run("OneByteReader", func(t *testing.T) {
+		r := iotest.OneByteReader(bytes.NewReader(stream))
+
+		var decodedPriv PrivateKey
+		n, err := decodedPriv.ReadFrom(r)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(KeySize*2), n)
+		assert.Equal(t, priv, decodedPriv)
+
+		var decodedPub PublicKey
+		n, err = decodedPub.ReadFrom(r)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(KeySize), n)
+		assert.Equal(t, pub, decodedPub)
+	})
+	t.Run("ShortRead", func(t *testing.T) {
+		var decodedPriv PrivateKey
+		n, err := decodedPriv.ReadFrom(iotest.OneByteReader(bytes.NewReader(stream[:KeySize])))
+		assert.Equal(t, io.ErrUnexpectedEOF, err)
+		assert.Equal(t, int64(KeySize), n)
+		assert.Equal(t, PrivateKey{}, decodedPriv, "key should be unchanged")
+
+		var decodedPub PublicKey
+		_, err = decodedPub.ReadFrom(bytes.NewReader(nil))
+		assert.Equal(t, io.EOF, err)
+	})
+	t.Run("KeyMismatch", func(t *testing.T) {
+		corrupted := priv
+		corrupted[KeySize] ^= 0xff
+		var decodedPriv PrivateKey
+		n, err := decodedPriv.ReadFrom(bytes.NewReader(corrupted[:]))
+		assert.Equal(t, ErrKeyMismatch, err)
+		assert.Equal(t, int64(KeySize*2), n)
+		assert.Equal(t, PrivateKey{}, decodedPriv, "key should be unchanged")
+	})
+}
+
+func TestClone(t *testing.T) {
+	k, err := Generate()
+	assert.NoError(t, err)
+	original := k
+
+	clone := k.Clone()
+	assert.Equal(t, k, clone)
+
+	clone[0] ^= 0xff
+	assert.Equal(t, original, k, "mutating the clone should not affect the original")
+
+	clone = k.Clone()
+	k.Zero()
+	assert.Equal(t, original, clone, "zeroing the original should not affect the clone")
+}
+
+func TestIsZero(t *testing.T) {
+	var priv PrivateKey
+	var pub PublicKey
+	assert.True(t, priv.IsZero())
+	assert.True(t, pub.IsZero())
+
+	priv, err := Generate()
+	assert.NoError(t, err)
+	assert.False(t, priv.IsZero())
+	assert.False(t, priv.PublicKey().IsZero())
+
+	priv.Zero()
+	assert.True(t, priv.IsZero())
+}
+
+func TestSetRandReader(t *testing.T) {
+	defer SetRandReader(nil)
+
+	encrypt := func() []byte {
+		SetRandReader(mathrand.New(mathrand.NewSource(1)))
+		k1, err := Generate()
+		assert.NoError(t, err)
+		k2, err := Generate()
+		assert.NoError(t, err)
+		return k1.Encrypt(k2.PublicKey(), []byte("Hello World"))
+	}
+	assert.Equal(t, encrypt(), encrypt())
+
+	SetRandReader(nil)
+	assert.Equal(t, rand.Reader, randReader)
+}
+
+type errReader struct{ err error }
+
+func (r errReader) Read([]byte) (int, error) { return 0, r.err }
+
+func TestEncryptSafe(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	msg := []byte("Hello World")
+
+	encrypted, err := k1.EncryptSafe(k2.PublicKey(), msg)
+	assert.NoError(t, err)
+	_, decrypted, err := k2.Decrypt(encrypted)
+	if assert.NoError(t, err) {
+		assert.Equal(t, msg, decrypted)
+	}
+
+	defer SetRandReader(nil)
+	SetRandReader(errReader{errors.New("no entropy")})
+
+	_, err = k1.EncryptSafe(k2.PublicKey(), msg)
+	assert.EqualError(t, err, "failed to generate nonce: no entropy")
+	assert.Panics(t, func() { k1.Encrypt(k2.PublicKey(), msg) })
+}
+
+func TestBytes(t *testing.T) {
+	k, err := Generate()
+	assert.NoError(t, err)
+	original := k
+	pub := k.PublicKey()
+
+	bs := k.Bytes()
+	assert.Equal(t, k[:], bs)
+	bs[0] ^= 0xff
+	assert.Equal(t, original, k, "mutating the bytes should not affect the key")
+
+	pbs := k.PublicBytes()
+	assert.Equal(t, pub[:], pbs)
+	pbs[0] ^= 0xff
+	assert.Equal(t, original, k, "mutating the public bytes should not affect the key")
+
+	pbs = pub.Bytes()
+	assert.Equal(t, k.PublicBytes(), pbs)
+	pbs[0] ^= 0xff
+	assert.Equal(t, original.PublicKey(), pub, "mutating the bytes should not affect the public key")
+}
+
+func TestDerivePublicKey(t *testing.T) {
+	// RFC 7748 section 6.1
+	scalar, _ := hex.DecodeString("77076d0a7318a57d3c16c17251b26645df4c2f87ebc0992ab177fba51db92c2a")
+	pub, err := DerivePublicKey(scalar)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "8520f0098930a754748b7ddcb43ef75a0dbf3a0d26381af4eba4a98eaa9b4e6a", hex.EncodeToString(pub[:]))
+	}
+	assert.Equal(t, "77076d0a7318a57d3c16c17251b26645df4c2f87ebc0992ab177fba51db92c2a", hex.EncodeToString(scalar),
+		"the input should not be modified")
+
+	k, err := Generate()
+	assert.NoError(t, err)
+	pub, err = DerivePublicKey(k[:KeySize])
+	if assert.NoError(t, err) {
+		assert.Equal(t, k.PublicKey(), pub)
+	}
+
+	_, err = DerivePublicKey(scalar[:KeySize-1])
+	assert.True(t, errors.Is(err, ErrInvalidKeyLength))
+	_, err = DerivePublicKey(k[:])
+	assert.EqualError(t, err, "invalid key: got 64 bytes, want 32")
+}
+
+func TestMaxMessageSize(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	defer func(size int) { MaxMessageSize = size }(MaxMessageSize)
+	MaxMessageSize = 1024
+
+	encrypted, err := k1.EncryptSafe(k2.PublicKey(), make([]byte, MaxMessageSize))
+	assert.NoError(t, err)
+	_, decrypted, err := k2.DecryptSafe(encrypted)
+	if assert.NoError(t, err) {
+		assert.Len(t, decrypted, MaxMessageSize)
+	}
+
+	_, err = k1.EncryptSafe(k2.PublicKey(), make([]byte, MaxMessageSize+1))
+	assert.True(t, errors.Is(err, ErrMessageTooLarge))
+
+	encrypted = k1.Encrypt(k2.PublicKey(), make([]byte, MaxMessageSize+1))
+	_, _, err = k2.DecryptSafe(encrypted)
+	assert.True(t, errors.Is(err, ErrMessageTooLarge))
+	_, _, err = k2.Decrypt(encrypted)
+	assert.NoError(t, err, "Decrypt should not enforce the limit")
+}
+
+func TestGenerateFromReaderExact(t *testing.T) {
+	seed := bytes.Repeat([]byte{0x42}, KeySize)
+
+	// any read beyond the first 32 bytes fails
+	r := iotest.OneByteReader(io.MultiReader(bytes.NewReader(seed), errReader{errors.New("read too much")}))
+	k, err := GenerateFromReaderExact(r)
+	if assert.NoError(t, err) {
+		assert.Equal(t, byte(0x40), k[0], "the scalar should be clamped")
+		assert.Equal(t, byte(0x42), k[31])
+
+		pub, err := DerivePublicKey(seed)
+		assert.NoError(t, err)
+		assert.Equal(t, pub, k.PublicKey())
+	}
+
+	_, err = r.Read(make([]byte, 1))
+	assert.EqualError(t, err, "read too much", "the reader should be positioned right after the 32 bytes")
+
+	_, err = GenerateFromReaderExact(bytes.NewReader(seed[:KeySize-1]))
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+}
+
+func TestDecryptFrom(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+	k3, err := Generate()
+	assert.NoError(t, err)
+
+	msg := []byte("Hello World")
+	encrypted := k1.Encrypt(k2.PublicKey(), msg)
+
+	decrypted, err := k2.DecryptFrom(k1.PublicKey(), encrypted)
+	if assert.NoError(t, err) {
+		assert.Equal(t, msg, decrypted)
+	}
+
+	decrypted, err = k2.DecryptFrom(k3.PublicKey(), encrypted)
+	assert.True(t, errors.Is(err, ErrUnexpectedSender))
+	assert.Nil(t, decrypted)
+
+	_, err = k3.DecryptFrom(k1.PublicKey(), encrypted)
+	assert.True(t, errors.Is(err, ErrOpenFailed))
+}
+
+func TestDecryptMin(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	sender, decrypted, err := k2.DecryptMin(k1.Encrypt(k2.PublicKey(), []byte("abcd")), 4)
+	if assert.NoError(t, err) {
+		assert.Equal(t, k1.PublicKey(), sender)
+		assert.Equal(t, "abcd", string(decrypted))
+	}
+
+	_, decrypted, err = k2.DecryptMin(k1.Encrypt(k2.PublicKey(), []byte("abc")), 4)
+	assert.True(t, errors.Is(err, ErrPlaintextTooShort))
+	assert.EqualError(t, err, "invalid message: plaintext too short: got 3 bytes, want at least 4")
+	assert.Nil(t, decrypted)
+
+	// authentication failures take precedence
+	encrypted := k1.Encrypt(k2.PublicKey(), []byte("abc"))
+	encrypted[len(encrypted)-1] ^= 0xff
+	_, _, err = k2.DecryptMin(encrypted, 4)
+	assert.True(t, errors.Is(err, ErrOpenFailed))
+	assert.False(t, errors.Is(err, ErrPlaintextTooShort))
+}
+
+func TestPlaintextLen(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	for _, size := range []int{0, 1, 100, 4096} {
+		msg := make([]byte, size)
+		encrypted := k1.Encrypt(k2.PublicKey(), msg)
+
+		n, err := PlaintextLen(encrypted)
+		if assert.NoError(t, err) {
+			assert.Equal(t, size, n)
+		}
+		n, err = PlaintextLen(encrypted[HeaderSize:])
+		if assert.NoError(t, err) {
+			assert.Equal(t, size, n, "legacy messages should be supported")
+		}
+
+		n, err = PlaintextLen(k1.EncryptTimestamped(k2.PublicKey(), msg))
+		if assert.NoError(t, err) {
+			assert.Equal(t, size, n, "the timestamp should not be counted")
+		}
+
+		// pre-size the buffer so decrypting doesn't allocate
+		buf := make([]byte, 0, n)
+		_, decrypted, err := k2.DecryptAppend(buf, encrypted)
+		if assert.NoError(t, err) && size > 0 {
+			assert.Equal(t, &buf[:1][0], &decrypted[0])
+		}
+	}
+
+	_, err = PlaintextLen(k1.Encrypt(k2.PublicKey(), nil)[:HeaderSize+KeySize+NonceSize])
+	assert.True(t, errors.Is(err, ErrShortMessage))
+	_, err = PlaintextLen(k1.EncryptTimestamped(k2.PublicKey(), nil)[:HeaderSize+KeySize+NonceSize+16])
+	assert.True(t, errors.Is(err, ErrShortMessage))
+	_, err = PlaintextLen(k1.EncryptCompressed(k2.PublicKey(), []byte("Hello World")))
+	assert.Error(t, err)
+	_, err = PlaintextLen(append([]byte{'R', 'T', 0x7f}, make([]byte, 100)...))
+	assert.True(t, errors.Is(err, ErrUnknownVersion))
+}
+
+func TestEncryptWithNonceOut(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	msg := []byte("Hello World")
+
+	encrypted, nonce := k1.EncryptWithNonceOut(k2.PublicKey(), msg)
+	assert.Equal(t, nonce[:], encrypted[HeaderSize+KeySize:HeaderSize+KeySize+NonceSize])
+	assert.Equal(t, k1.EncryptWithNonce(k2.PublicKey(), nonce, msg), encrypted)
+
+	_, decrypted, err := k2.Decrypt(encrypted)
+	if assert.NoError(t, err) {
+		assert.Equal(t, msg, decrypted)
+	}
+
+	_, other := k1.EncryptWithNonceOut(k2.PublicKey(), msg)
+	assert.NotEqual(t, nonce, other)
+}
+
+func TestDecryptPeekSender(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	for _, encrypted := range [][]byte{
+		k1.Encrypt(k2.PublicKey(), []byte("Hello World")),
+		k1.EncryptCompressed(k2.PublicKey(), []byte("Hello World")),
+		k1.Encrypt(k2.PublicKey(), nil)[HeaderSize:],
+	} {
+		pub, err := DecryptPeekSender(encrypted)
+		if assert.NoError(t, err) {
+			assert.Equal(t, k1.PublicKey(), pub)
+		}
+	}
+
+	// only the public key is needed
+	pub, err := DecryptPeekSender(k1.Encrypt(k2.PublicKey(), nil)[:HeaderSize+KeySize])
+	if assert.NoError(t, err) {
+		assert.Equal(t, k1.PublicKey(), pub)
+	}
+
+	_, err = DecryptPeekSender(k1.Encrypt(k2.PublicKey(), nil)[:HeaderSize+KeySize-1])
+	assert.True(t, errors.Is(err, ErrShortMessage))
+	_, err = DecryptPeekSender(make([]byte, KeySize-1))
+	assert.True(t, errors.Is(err, ErrShortMessage))
+	_, err = DecryptPeekSender(nil)
+	assert.True(t, errors.Is(err, ErrShortMessage))
+	_, err = DecryptPeekSender(append([]byte{'R', 'T', 0x7f}, make([]byte, KeySize)...))
+	assert.True(t, errors.Is(err, ErrUnknownVersion))
+}
+
+func TestGenerateN(t *testing.T) {
+	keys, err := GenerateN(context.Background(), 100)
+	assert.NoError(t, err)
+	assert.Len(t, keys, 100)
+
+	seen := map[PrivateKey]bool{}
+	for _, key := range keys {
+		assert.False(t, key.IsZero())
+		assert.False(t, seen[key], "keys should be unique")
+		seen[key] = true
+	}
+
+	keys, err = GenerateN(context.Background(), 0)
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+
+	_, err = GenerateN(context.Background(), -1)
+	assert.Error(t, err)
+
+	t.Run("Canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		keys, err := GenerateN(ctx, 100)
+		assert.Equal(t, context.Canceled, err)
+		assert.Empty(t, keys)
+	})
+	t.Run("CanceledPartway", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		// cancel once a few keys have been generated
+		defer SetRandReader(nil)
+		var reads int64
+		SetRandReader(readerFunc(func(p []byte) (int, error) {
+			if atomic.AddInt64(&reads, 1) == 10 {
+				cancel()
+			}
+			return rand.Read(p)
+		}))
+
+		keys, err := GenerateN(ctx, 1000)
+		assert.Equal(t, context.Canceled, err)
+		assert.NotEmpty(t, keys)
+		assert.True(t, len(keys) < 1000)
+		for _, key := range keys {
+			assert.False(t, key.IsZero(), "only generated keys should be returned")
+		}
+	})
+	t.Run("Error", func(t *testing.T) {
+		defer SetRandReader(nil)
+		SetRandReader(errReader{errors.New("no entropy")})
+
+		keys, err := GenerateN(context.Background(), 10)
+		assert.EqualError(t, err, "no entropy")
+		assert.Empty(t, keys)
+	})
+}
+
+func TestNonceString(t *testing.T) {
+	nonce, err := generateNonce()
+	assert.NoError(t, err)
+
+	parsed, err := ParseNonce(nonce.String())
+	assert.NoError(t, err)
+	assert.Equal(t, nonce, parsed)
+
+	var zero Nonce
+	parsed, err = ParseNonce(zero.String())
+	assert.NoError(t, err)
+	assert.Equal(t, zero, parsed)
+
+	arr := nonce.Array()
+	assert.Equal(t, nonce, NewNonce(arr))
+
+	_, err = ParseNonce(zero.String()[:10])
+	assert.EqualError(t, err, "invalid nonce: got 10 bytes, want 24")
+	_, err = ParseNonce("0OIl")
+	assert.Error(t, err)
+}
+
+func TestEncryptv(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	for _, segments := range [][][]byte{
+		{[]byte("header:"), []byte("body")},
+		{[]byte("Hello"), nil, []byte(" "), {}, []byte("World")},
+		{},
+	} {
+		encrypted := k1.Encryptv(k2.PublicKey(), segments...)
+		assert.Equal(t, len(encrypted), cap(encrypted))
+
+		sender, decrypted, err := k2.Decrypt(encrypted)
+		if assert.NoError(t, err) {
+			assert.Equal(t, k1.PublicKey(), sender)
+			assert.Equal(t, string(bytes.Join(segments, nil)), string(decrypted))
+		}
+	}
+}
+
+func BenchmarkEncryptv(b *testing.B) {
+	k, _ := Generate()
+	header := make([]byte, 64)
+	body := make([]byte, 4096)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		k.Encryptv(k.PublicKey(), header, body)
+	}
+}
+
+type readerFunc func([]byte) (int, error)
+
+func (f readerFunc) Read(p []byte) (int, error) { return f(p) }
+
+func BenchmarkGenerateN(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = GenerateN(context.Background(), 1000)
+	}
+}
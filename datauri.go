@@ -0,0 +1,47 @@
+package crypt
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	dataURIScheme = "data:"
+	dataURIBase64 = ";base64"
+)
+
+// EncryptDataURI encrypts data for the peer public key and returns the message as an RFC 2397 data URI of the form
+// data:<mediaType>;base64,<message>.
+func (key PrivateKey) EncryptDataURI(peersPublicKey PublicKey, data []byte, mediaType string) string {
+	return dataURIScheme + mediaType + dataURIBase64 + "," + base64.StdEncoding.EncodeToString(key.Encrypt(peersPublicKey, data))
+}
+
+// DecryptDataURI decrypts a data URI produced by EncryptDataURI. The URI must use the data scheme, be base64
+// encoded and declare the expected media type, which is compared case-insensitively.
+func (key PrivateKey) DecryptDataURI(uri, mediaType string) (PublicKey, []byte, error) {
+	if len(uri) < len(dataURIScheme) || !strings.EqualFold(uri[:len(dataURIScheme)], dataURIScheme) {
+		return PublicKey{}, nil, decryptFailed(errors.New("invalid data URI: expected data scheme"))
+	}
+	uri = uri[len(dataURIScheme):]
+
+	i := strings.IndexByte(uri, ',')
+	if i < 0 {
+		return PublicKey{}, nil, decryptFailed(errors.New("invalid data URI: expected data"))
+	}
+	meta, payload := uri[:i], uri[i+1:]
+
+	if !strings.HasSuffix(meta, dataURIBase64) {
+		return PublicKey{}, nil, decryptFailed(errors.New("invalid data URI: expected base64 encoding"))
+	}
+	if actual := strings.TrimSuffix(meta, dataURIBase64); !strings.EqualFold(actual, mediaType) {
+		return PublicKey{}, nil, decryptFailed(fmt.Errorf("invalid data URI: unexpected media type %q, want %q", actual, mediaType))
+	}
+
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return PublicKey{}, nil, decryptFailed(fmt.Errorf("invalid data URI: %w", err))
+	}
+	return key.Decrypt(data)
+}
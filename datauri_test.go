@@ -0,0 +1,43 @@
+package crypt
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncryptDataURI(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	uri := k1.EncryptDataURI(k2.PublicKey(), []byte("Hello World"), "application/octet-stream")
+	assert.True(t, strings.HasPrefix(uri, "data:application/octet-stream;base64,UlQB"), uri)
+
+	for _, mediaType := range []string{"application/octet-stream", "Application/Octet-Stream"} {
+		sender, decrypted, err := k2.DecryptDataURI(uri, mediaType)
+		if assert.NoError(t, err) {
+			assert.Equal(t, k1.PublicKey(), sender)
+			assert.Equal(t, "Hello World", string(decrypted))
+		}
+	}
+
+	payload := uri[strings.IndexByte(uri, ',')+1:]
+	for _, tc := range []struct {
+		uri, err string
+	}{
+		{"http:application/octet-stream;base64," + payload, "invalid data URI: expected data scheme"},
+		{"data", "invalid data URI: expected data scheme"},
+		{"data:application/octet-stream;base64" + payload, "invalid data URI: expected data"},
+		{"data:application/octet-stream," + payload, "invalid data URI: expected base64 encoding"},
+		{"data:text/plain;base64," + payload, `invalid data URI: unexpected media type "text/plain", want "application/octet-stream"`},
+	} {
+		_, _, err := k2.DecryptDataURI(tc.uri, "application/octet-stream")
+		assert.EqualError(t, err, tc.err, tc.uri)
+	}
+
+	_, _, err = k2.DecryptDataURI("data:application/octet-stream;base64,not base64!", "application/octet-stream")
+	assert.Error(t, err)
+}
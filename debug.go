@@ -0,0 +1,61 @@
+package crypt
+
+import (
+	"golang.org/x/crypto/nacl/box"
+)
+
+// DecryptDiagnostics describes how far a message got through decryption. It helps to tell truncated messages from
+// tampered ones and never includes plaintext or key material.
+type DecryptDiagnostics struct {
+	// Header reports whether the message starts with a header, MessageType is the type it declares.
+	Header      bool
+	MessageType byte
+	// HasPublicKey and HasNonce report whether the message is long enough to contain the sender's public key and
+	// the nonce.
+	HasPublicKey bool
+	HasNonce     bool
+	// Nonce is the parsed nonce, if the message contains one.
+	Nonce Nonce
+	// CiphertextLength is the length of the sealed box following the nonce. A sealed box is at least box.Overhead
+	// bytes long, anything shorter is truncated.
+	CiphertextLength int
+	// Offset is the byte offset into the message of the part that was missing or failed to authenticate, or -1 if
+	// decryption succeeded.
+	Offset int
+}
+
+// Truncated reports whether the message is too short to be complete.
+func (d *DecryptDiagnostics) Truncated() bool {
+	return !d.HasPublicKey || !d.HasNonce || d.CiphertextLength < box.Overhead
+}
+
+// DecryptDebug is like Decrypt, but also returns diagnostics about the message.
+func (key PrivateKey) DecryptDebug(data []byte) (PublicKey, []byte, *DecryptDiagnostics, error) {
+	diag := &DecryptDiagnostics{}
+
+	typ, body, ok := parseHeader(data)
+	if ok {
+		diag.Header = true
+		diag.MessageType = typ
+	}
+	diag.Offset = len(data) - len(body)
+
+	if len(body) >= KeySize {
+		diag.HasPublicKey = true
+		diag.Offset += KeySize
+		body = body[KeySize:]
+
+		if len(body) >= NonceSize {
+			diag.HasNonce = true
+			copy(diag.Nonce[:], body)
+			diag.Offset += NonceSize
+			diag.CiphertextLength = len(body) - NonceSize
+		}
+	}
+
+	pub, opened, err := key.Decrypt(data)
+	if err == nil {
+		diag.Offset = -1
+	}
+	return pub, opened, diag, err
+}
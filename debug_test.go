@@ -0,0 +1,86 @@
+package crypt
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecryptDebug(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	msg := []byte("Hello World")
+	encrypted := k1.Encrypt(k2.PublicKey(), msg)
+
+	var nonce Nonce
+	copy(nonce[:], encrypted[HeaderSize+KeySize:])
+
+	t.Run("OK", func(t *testing.T) {
+		pub, decrypted, diag, err := k2.DecryptDebug(encrypted)
+		if assert.NoError(t, err) {
+			assert.Equal(t, k1.PublicKey(), pub)
+			assert.Equal(t, msg, decrypted)
+		}
+		assert.Equal(t, &DecryptDiagnostics{
+			Header:           true,
+			MessageType:      MessageTypeBox,
+			HasPublicKey:     true,
+			HasNonce:         true,
+			Nonce:            nonce,
+			CiphertextLength: len(msg) + 16,
+			Offset:           -1,
+		}, diag)
+		assert.False(t, diag.Truncated())
+	})
+	t.Run("MissingPublicKey", func(t *testing.T) {
+		_, _, diag, err := k2.DecryptDebug(encrypted[:HeaderSize+10])
+		assert.True(t, errors.Is(err, ErrShortMessage))
+		assert.Equal(t, &DecryptDiagnostics{
+			Header:      true,
+			MessageType: MessageTypeBox,
+			Offset:      HeaderSize,
+		}, diag)
+		assert.True(t, diag.Truncated())
+	})
+	t.Run("MissingNonce", func(t *testing.T) {
+		_, _, diag, err := k2.DecryptDebug(encrypted[:HeaderSize+KeySize+10])
+		assert.True(t, errors.Is(err, ErrMissingNonce))
+		assert.Equal(t, &DecryptDiagnostics{
+			Header:       true,
+			MessageType:  MessageTypeBox,
+			HasPublicKey: true,
+			Offset:       HeaderSize + KeySize,
+		}, diag)
+		assert.True(t, diag.Truncated())
+	})
+	t.Run("TruncatedCiphertext", func(t *testing.T) {
+		_, _, diag, err := k2.DecryptDebug(encrypted[:HeaderSize+KeySize+NonceSize+10])
+		assert.True(t, errors.Is(err, ErrOpenFailed))
+		assert.Equal(t, 10, diag.CiphertextLength)
+		assert.Equal(t, HeaderSize+KeySize+NonceSize, diag.Offset)
+		assert.True(t, diag.Truncated())
+	})
+	t.Run("Tampered", func(t *testing.T) {
+		tampered := append([]byte(nil), encrypted...)
+		tampered[len(tampered)-1] ^= 0xff
+		_, decrypted, diag, err := k2.DecryptDebug(tampered)
+		assert.True(t, errors.Is(err, ErrOpenFailed))
+		assert.Nil(t, decrypted)
+		assert.Equal(t, nonce, diag.Nonce)
+		assert.Equal(t, len(msg)+16, diag.CiphertextLength)
+		assert.Equal(t, HeaderSize+KeySize+NonceSize, diag.Offset)
+		assert.False(t, diag.Truncated(), "a tampered message isn't truncated")
+	})
+	t.Run("Legacy", func(t *testing.T) {
+		_, decrypted, diag, err := k2.DecryptDebug(encrypted[HeaderSize:])
+		if assert.NoError(t, err) {
+			assert.Equal(t, msg, decrypted)
+		}
+		assert.False(t, diag.Header)
+		assert.Equal(t, nonce, diag.Nonce)
+	})
+}
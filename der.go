@@ -0,0 +1,101 @@
+package crypt
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+)
+
+// oidX25519 is the algorithm identifier of X25519 keys, from RFC 8410.
+var oidX25519 = asn1.ObjectIdentifier{1, 3, 101, 110}
+
+// pkcs8 is the PKCS#8 structure of a private key, from RFC 5208. Optional attributes are omitted.
+type pkcs8 struct {
+	Version    int
+	Algo       pkix.AlgorithmIdentifier
+	PrivateKey []byte
+}
+
+// subjectPublicKeyInfo is the X.509 structure of a public key, from RFC 5280.
+type subjectPublicKeyInfo struct {
+	Algo      pkix.AlgorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+// MarshalDER returns the private key as an RFC 8410 PKCS#8 DER structure, which crypto/x509 and `openssl pkey
+// -inform DER` both understand. Only the 32 byte private scalar is encoded.
+func (key PrivateKey) MarshalDER() ([]byte, error) {
+	// the private key is itself wrapped as a CurvePrivateKey octet string
+	scalar, err := asn1.Marshal(key[:KeySize])
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(pkcs8{
+		Algo:       pkix.AlgorithmIdentifier{Algorithm: oidX25519},
+		PrivateKey: scalar,
+	})
+}
+
+// ParsePrivateKeyDER parses an RFC 8410 PKCS#8 DER encoded X25519 private key, as returned by MarshalDER. Keys of
+// any other algorithm are rejected.
+func ParsePrivateKeyDER(der []byte) (PrivateKey, error) {
+	var info pkcs8
+	if rest, err := asn1.Unmarshal(der, &info); err != nil {
+		return PrivateKey{}, fmt.Errorf("invalid key: %w", err)
+	} else if len(rest) > 0 {
+		return PrivateKey{}, errors.New("invalid key: trailing data after PKCS#8 structure")
+	}
+	if err := checkX25519Algorithm(info.Algo); err != nil {
+		return PrivateKey{}, err
+	}
+
+	var scalar []byte
+	if rest, err := asn1.Unmarshal(info.PrivateKey, &scalar); err != nil {
+		return PrivateKey{}, fmt.Errorf("invalid key: %w", err)
+	} else if len(rest) > 0 {
+		return PrivateKey{}, errors.New("invalid key: trailing data after private key")
+	}
+	if len(scalar) != KeySize {
+		return PrivateKey{}, fmt.Errorf("%w: got %d bytes, want %d", ErrInvalidKeyLength, len(scalar), KeySize)
+	}
+	return newPrivateKeyFromScalar(scalar)
+}
+
+// MarshalDER returns the public key as an RFC 8410 SubjectPublicKeyInfo DER structure.
+func (key PublicKey) MarshalDER() ([]byte, error) {
+	return asn1.Marshal(subjectPublicKeyInfo{
+		Algo:      pkix.AlgorithmIdentifier{Algorithm: oidX25519},
+		PublicKey: asn1.BitString{Bytes: key[:], BitLength: KeySize * 8},
+	})
+}
+
+// ParsePublicKeyDER parses an RFC 8410 SubjectPublicKeyInfo DER encoded X25519 public key, as returned by
+// MarshalDER. Keys of any other algorithm are rejected.
+func ParsePublicKeyDER(der []byte) (PublicKey, error) {
+	var info subjectPublicKeyInfo
+	if rest, err := asn1.Unmarshal(der, &info); err != nil {
+		return PublicKey{}, fmt.Errorf("invalid key: %w", err)
+	} else if len(rest) > 0 {
+		return PublicKey{}, errors.New("invalid key: trailing data after public key info")
+	}
+	if err := checkX25519Algorithm(info.Algo); err != nil {
+		return PublicKey{}, err
+	}
+	if info.PublicKey.BitLength != len(info.PublicKey.Bytes)*8 {
+		return PublicKey{}, errors.New("invalid key: public key is not a whole number of bytes")
+	}
+	return parsePublicKey(info.PublicKey.Bytes)
+}
+
+// checkX25519Algorithm returns an error unless the algorithm identifier is the RFC 8410 X25519 identifier, which has
+// no parameters.
+func checkX25519Algorithm(algo pkix.AlgorithmIdentifier) error {
+	if !algo.Algorithm.Equal(oidX25519) {
+		return fmt.Errorf("invalid key: unsupported algorithm %v, want X25519 (%v)", algo.Algorithm, oidX25519)
+	}
+	if len(algo.Parameters.FullBytes) != 0 {
+		return errors.New("invalid key: unexpected X25519 algorithm parameters")
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+//go:build go1.20
+// +build go1.20
+
+package crypt
+
+import (
+	"crypto/ecdh"
+	"crypto/x509"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// crypto/x509 supports X25519 keys since Go 1.20.
+
+func TestPrivateKeyDERX509(t *testing.T) {
+	priv, err := Generate()
+	assert.NoError(t, err)
+	der, err := priv.MarshalDER()
+	assert.NoError(t, err)
+
+	parsed, err := x509.ParsePKCS8PrivateKey(der)
+	if !assert.NoError(t, err) {
+		return
+	}
+	if k, ok := parsed.(*ecdh.PrivateKey); assert.True(t, ok, "%T", parsed) {
+		assert.Equal(t, ecdh.X25519(), k.Curve())
+		assert.Equal(t, priv[:KeySize], k.Bytes())
+		assert.Equal(t, priv[KeySize:], k.PublicKey().Bytes())
+	}
+
+	marshaled, err := x509.MarshalPKCS8PrivateKey(parsed)
+	assert.NoError(t, err)
+	assert.Equal(t, der, marshaled)
+}
+
+func TestPublicKeyDERX509(t *testing.T) {
+	priv, err := Generate()
+	assert.NoError(t, err)
+	pub := priv.PublicKey()
+	der, err := pub.MarshalDER()
+	assert.NoError(t, err)
+
+	parsed, err := x509.ParsePKIXPublicKey(der)
+	if !assert.NoError(t, err) {
+		return
+	}
+	if k, ok := parsed.(*ecdh.PublicKey); assert.True(t, ok, "%T", parsed) {
+		assert.Equal(t, ecdh.X25519(), k.Curve())
+		assert.Equal(t, pub[:], k.Bytes())
+	}
+
+	marshaled, err := x509.MarshalPKIXPublicKey(parsed)
+	assert.NoError(t, err)
+	assert.Equal(t, der, marshaled)
+}
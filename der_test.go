@@ -0,0 +1,67 @@
+package crypt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrivateKeyDER(t *testing.T) {
+	priv, err := Generate()
+	assert.NoError(t, err)
+
+	der, err := priv.MarshalDER()
+	assert.NoError(t, err)
+
+	decoded, err := ParsePrivateKeyDER(der)
+	if assert.NoError(t, err) {
+		assert.Equal(t, priv, decoded)
+	}
+
+	t.Run("OtherAlgorithm", func(t *testing.T) {
+		ec, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		assert.NoError(t, err)
+		der, err := x509.MarshalPKCS8PrivateKey(ec)
+		assert.NoError(t, err)
+
+		_, err = ParsePrivateKeyDER(der)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "unsupported algorithm 1.2.840.10045.2.1")
+	})
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := ParsePrivateKeyDER(der[:len(der)-1])
+		assert.Error(t, err)
+		_, err = ParsePrivateKeyDER(append(der, 0))
+		assert.Error(t, err)
+	})
+}
+
+func TestPublicKeyDER(t *testing.T) {
+	priv, err := Generate()
+	assert.NoError(t, err)
+	pub := priv.PublicKey()
+
+	der, err := pub.MarshalDER()
+	assert.NoError(t, err)
+
+	decoded, err := ParsePublicKeyDER(der)
+	if assert.NoError(t, err) {
+		assert.Equal(t, pub, decoded)
+	}
+
+	t.Run("OtherAlgorithm", func(t *testing.T) {
+		ec, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		assert.NoError(t, err)
+		der, err := x509.MarshalPKIXPublicKey(&ec.PublicKey)
+		assert.NoError(t, err)
+
+		_, err = ParsePublicKeyDER(der)
+		assert.Error(t, err)
+		assert.False(t, errors.Is(err, ErrInvalidKeyLength))
+	})
+}
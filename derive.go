@@ -0,0 +1,56 @@
+package crypt
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// DeriveSubkey derives a symmetric key of the given length for a specific purpose from the shared key with the peer.
+// The shared key is expanded with HKDF-SHA256 using info as the label, so different labels result in independent
+// keys.
+func (key PrivateKey) DeriveSubkey(peer PublicKey, info []byte, length int) ([]byte, error) {
+	if length <= 0 || length > 255*sha256.Size {
+		return nil, errors.New("invalid subkey length")
+	}
+
+	shared := key.Precompute(peer)
+
+	subkey := make([]byte, length)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, shared[:], nil, info), subkey); err != nil {
+		return nil, err
+	}
+	return subkey, nil
+}
+
+// DeriveFromIdentity deterministically derives a private key for an identity, such as a hostname, from a master
+// seed. The seed is expanded with HKDF-SHA256 using the identity as the label and the result is clamped to give the
+// private scalar, so every identity gets an independent key. The seed must be at least 32 bytes.
+func DeriveFromIdentity(masterSeed []byte, identity string) (PrivateKey, error) {
+	if len(masterSeed) < KeySize {
+		return PrivateKey{}, fmt.Errorf("invalid seed: expected at least %d bytes, got %d", KeySize, len(masterSeed))
+	}
+
+	scalar := make([]byte, KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, masterSeed, nil, []byte(identity)), scalar); err != nil {
+		return PrivateKey{}, err
+	}
+	scalar[0] &= 248
+	scalar[31] &= 127
+	scalar[31] |= 64
+	return newPrivateKeyFromScalar(scalar)
+}
+
+// Derive deterministically derives a child key for a hierarchical path such as "org", "region", "device". Each
+// segment derives a child of the previous key as DeriveFromIdentity does, with the parent's private scalar as the
+// seed, so root.Derive("a", "b") equals root.Derive("a").Derive("b"). Without a path the key itself is returned.
+func (key PrivateKey) Derive(path ...string) PrivateKey {
+	for _, segment := range path {
+		// the scalar is long enough, so deriving can't fail
+		key, _ = DeriveFromIdentity(key[:KeySize], segment)
+	}
+	return key
+}
@@ -0,0 +1,88 @@
+package crypt
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeriveSubkey(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	enc1, err := k1.DeriveSubkey(k2.PublicKey(), []byte("encryption"), 32)
+	assert.NoError(t, err)
+	assert.Len(t, enc1, 32)
+
+	enc2, err := k2.DeriveSubkey(k1.PublicKey(), []byte("encryption"), 32)
+	assert.NoError(t, err)
+	assert.Equal(t, enc1, enc2, "both sides should derive the same key")
+
+	mac, err := k1.DeriveSubkey(k2.PublicKey(), []byte("mac"), 32)
+	assert.NoError(t, err)
+	assert.NotEqual(t, enc1, mac)
+
+	long, err := k1.DeriveSubkey(k2.PublicKey(), []byte("encryption"), 64)
+	assert.NoError(t, err)
+	assert.Len(t, long, 64)
+
+	_, err = k1.DeriveSubkey(k2.PublicKey(), nil, 0)
+	assert.Error(t, err)
+	_, err = k1.DeriveSubkey(k2.PublicKey(), nil, 255*32+1)
+	assert.Error(t, err)
+}
+
+func TestDeriveFromIdentity(t *testing.T) {
+	seed := make([]byte, 32)
+	for i := range seed {
+		seed[i] = byte(i)
+	}
+
+	k1, err := DeriveFromIdentity(seed, "host1.example.com")
+	assert.NoError(t, err)
+	pub := k1.PublicKey()
+	assert.Equal(t, "6c8137c3a2be07c88976d9d98a849b20e9ae5c024d23181537d3ba7eede73256", hex.EncodeToString(pub[:]))
+
+	again, err := DeriveFromIdentity(seed, "host1.example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, k1, again, "the same identity should derive the same key")
+
+	k2, err := DeriveFromIdentity(seed, "host2.example.com")
+	assert.NoError(t, err)
+	assert.NotEqual(t, k1, k2, "distinct identities should derive distinct keys")
+
+	seed[0] ^= 0xff
+	k3, err := DeriveFromIdentity(seed, "host1.example.com")
+	assert.NoError(t, err)
+	assert.NotEqual(t, k1, k3, "distinct seeds should derive distinct keys")
+
+	_, err = DeriveFromIdentity(seed[:KeySize-1], "host1.example.com")
+	assert.Error(t, err)
+}
+
+func TestDerive(t *testing.T) {
+	root, err := Generate()
+	assert.NoError(t, err)
+
+	child := root.Derive("org", "region", "device")
+	assert.Equal(t, child, root.Derive("org", "region", "device"), "the same path should derive the same key")
+	assert.Equal(t, child, root.Derive("org").Derive("region").Derive("device"))
+	assert.Equal(t, root.Derive("a", "b"), root.Derive("a").Derive("b"))
+	assert.Equal(t, root, root.Derive())
+
+	assert.NotEqual(t, child, root.Derive("org", "region", "other"))
+	assert.NotEqual(t, root.Derive("a", "b"), root.Derive("b", "a"))
+	assert.NotEqual(t, root.Derive("ab"), root.Derive("a", "b"))
+
+	derived, err := DeriveFromIdentity(root[:KeySize], "org")
+	assert.NoError(t, err)
+	assert.Equal(t, derived, root.Derive("org"))
+
+	// children are usable key pairs
+	pub, err := DerivePublicKey(child[:KeySize])
+	assert.NoError(t, err)
+	assert.Equal(t, pub, child.PublicKey())
+}
@@ -0,0 +1,82 @@
+package crypt
+
+import (
+	"fmt"
+
+	"golang.org/x/crypto/nacl/box"
+)
+
+// SealDetached encrypts data using the private key intended for the peer public key, returning the nonce separately
+// from the ciphertext. Neither the sender's public key nor a header are included.
+func (key PrivateKey) SealDetached(peer PublicKey, data []byte) (nonce Nonce, ciphertext []byte) {
+	var priv [KeySize]byte
+	copy(priv[:], key[:KeySize])
+
+	var pub [KeySize]byte
+	copy(pub[:], peer[:])
+
+	nonce = mustGenerateNonce()
+	ciphertext = box.Seal(nil, data, nonce.ptr(), &pub, &priv)
+	return nonce, ciphertext
+}
+
+// OpenDetached decrypts a ciphertext produced by SealDetached by the peer.
+func (key PrivateKey) OpenDetached(peer PublicKey, nonce Nonce, ciphertext []byte) ([]byte, error) {
+	if err := peer.Validate(); err != nil {
+		return nil, decryptFailed(fmt.Errorf("invalid message: %w", err))
+	}
+
+	var priv [KeySize]byte
+	copy(priv[:], key[:KeySize])
+
+	var pub [KeySize]byte
+	copy(pub[:], peer[:])
+
+	opened, ok := box.Open(nil, ciphertext, nonce.ptr(), &pub, &priv)
+	if !ok {
+		return nil, decryptFailed(fmt.Errorf("invalid message: %w", ErrOpenFailed))
+	}
+	return opened, nil
+}
+
+// EncryptNoSender is like Encrypt, but omits the header and the sender's public key: the result is just the nonce
+// followed by the ciphertext. The peer must already know the sender's public key to decrypt it via
+// DecryptWithSender.
+func (key PrivateKey) EncryptNoSender(peer PublicKey, data []byte) []byte {
+	var priv [KeySize]byte
+	copy(priv[:], key[:KeySize])
+
+	var pub [KeySize]byte
+	copy(pub[:], peer[:])
+
+	nonce := mustGenerateNonce()
+
+	result := make([]byte, 0, NonceSize+len(data)+box.Overhead)
+	result = append(result, nonce[:]...)
+	return box.Seal(result, data, nonce.ptr(), &pub, &priv)
+}
+
+// DecryptWithSender decrypts data that was encrypted via EncryptNoSender by the sender.
+func (key PrivateKey) DecryptWithSender(sender PublicKey, data []byte) ([]byte, error) {
+	if len(data) < NonceSize {
+		return nil, decryptFailed(fmt.Errorf("invalid message: expected nonce: %w", ErrMissingNonce))
+	}
+
+	var nonce Nonce
+	copy(nonce[:], data[:])
+	data = data[NonceSize:]
+
+	return key.OpenDetached(sender, nonce, data)
+}
+
+// SealLibsodium encrypts data for the peer public key in the layout used by libsodium: the nonce followed by the
+// output of crypto_box_easy. The public keys must be exchanged out of band.
+func (key PrivateKey) SealLibsodium(peer PublicKey, data []byte) []byte {
+	return key.EncryptNoSender(peer, data)
+}
+
+// OpenLibsodium decrypts a nonce followed by the output of libsodium's crypto_box_easy, as produced by
+// SealLibsodium, sent by the peer.
+func (key PrivateKey) OpenLibsodium(peer PublicKey, data []byte) ([]byte, error) {
+	return key.DecryptWithSender(peer, data)
+}
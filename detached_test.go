@@ -0,0 +1,92 @@
+package crypt
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/mr-tron/base58"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSealDetached(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	msg := []byte("Hello World")
+
+	nonce, ciphertext := k1.SealDetached(k2.PublicKey(), msg)
+	opened, err := k2.OpenDetached(k1.PublicKey(), nonce, ciphertext)
+	if assert.NoError(t, err) {
+		assert.Equal(t, msg, opened)
+	}
+
+	otherNonce, _ := k1.SealDetached(k2.PublicKey(), msg)
+	_, err = k2.OpenDetached(k1.PublicKey(), otherNonce, ciphertext)
+	assert.True(t, errors.Is(err, ErrOpenFailed))
+}
+
+func TestEncryptNoSender(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	msg := []byte("Hello World")
+
+	encrypted := k1.EncryptNoSender(k2.PublicKey(), msg)
+	assert.Len(t, encrypted, NonceSize+len(msg)+16)
+
+	decrypted, err := k2.DecryptWithSender(k1.PublicKey(), encrypted)
+	if assert.NoError(t, err) {
+		assert.Equal(t, msg, decrypted)
+	}
+
+	other, err := Generate()
+	assert.NoError(t, err)
+	_, err = k2.DecryptWithSender(other.PublicKey(), encrypted)
+	assert.True(t, errors.Is(err, ErrOpenFailed))
+
+	_, err = k2.DecryptWithSender(k1.PublicKey(), encrypted[:NonceSize-1])
+	assert.True(t, errors.Is(err, ErrMissingNonce))
+}
+
+func TestLibsodium(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	msg := []byte("Hello World")
+
+	sealed := k1.SealLibsodium(k2.PublicKey(), msg)
+	assert.Len(t, sealed, NonceSize+len(msg)+16)
+
+	opened, err := k2.OpenLibsodium(k1.PublicKey(), sealed)
+	if assert.NoError(t, err) {
+		assert.Equal(t, msg, opened)
+	}
+
+	_, err = k2.OpenLibsodium(k2.PublicKey(), sealed)
+	assert.True(t, errors.Is(err, ErrOpenFailed))
+}
+
+func TestLibsodiumVector(t *testing.T) {
+	// generated with libsodium's crypto_box_easy, the nonce prepended
+	sender, _ := hex.DecodeString("0102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f20")
+	recipient, _ := hex.DecodeString("2122232425262728292a2b2c2d2e2f303132333435363738393a3b3c3d3e3f40")
+	sealed, _ := hex.DecodeString("4142434445464748494a4b4c4d4e4f505152535455565758" +
+		"01a7d787ebbdee03a70f35fd83accf7f0f9e92407a15f338243d3d949b0cba8cc315613a")
+
+	k1, err := NewPrivateKey(base58.Encode(sender))
+	assert.NoError(t, err)
+	k2, err := NewPrivateKey(base58.Encode(recipient))
+	assert.NoError(t, err)
+
+	opened, err := k2.OpenLibsodium(k1.PublicKey(), sealed)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "libsodium crypto box", string(opened))
+	}
+}
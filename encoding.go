@@ -0,0 +1,151 @@
+package crypt
+
+import (
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// PEM block types used for keys.
+const (
+	PEMPrivateKeyType = "RTCTUNNEL PRIVATE KEY"
+	PEMPublicKeyType  = "RTCTUNNEL PUBLIC KEY"
+)
+
+// NewPrivateKeyFromHex creates a new key from a hex string. Like NewPrivateKey both the 64 byte and the 32 byte
+// private-only forms are accepted.
+func NewPrivateKeyFromHex(str string) (PrivateKey, error) {
+	bs, err := hex.DecodeString(str)
+	if err != nil {
+		return PrivateKey{}, err
+	}
+	return parsePrivateKey(bs)
+}
+
+// HexString returns the hex encoded representation of the private key.
+func (key PrivateKey) HexString() string {
+	return hex.EncodeToString(key[:])
+}
+
+// NewPublicKeyFromHex creates a new key from a hex string.
+func NewPublicKeyFromHex(str string) (PublicKey, error) {
+	bs, err := hex.DecodeString(str)
+	if err != nil {
+		return PublicKey{}, err
+	}
+	return parsePublicKey(bs)
+}
+
+// HexString returns the hex encoded public key.
+func (key PublicKey) HexString() string {
+	return hex.EncodeToString(key[:])
+}
+
+// MarshalPEM returns the PEM encoded private key.
+func (key PrivateKey) MarshalPEM() []byte {
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  PEMPrivateKeyType,
+		Bytes: key[:],
+	})
+}
+
+// ParsePrivateKeyPEM parses a PEM encoded private key.
+func ParsePrivateKeyPEM(data []byte) (PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return PrivateKey{}, errors.New("invalid key: no PEM block found")
+	}
+	if block.Type != PEMPrivateKeyType {
+		return PrivateKey{}, fmt.Errorf("invalid key: unexpected PEM block type %q", block.Type)
+	}
+	return NewPrivateKeyFromBytes(block.Bytes)
+}
+
+// MarshalPEM returns the PEM encoded public key.
+func (key PublicKey) MarshalPEM() []byte {
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  PEMPublicKeyType,
+		Bytes: key[:],
+	})
+}
+
+// ParsePublicKeyPEM parses a PEM encoded public key.
+func ParsePublicKeyPEM(data []byte) (PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return PublicKey{}, errors.New("invalid key: no PEM block found")
+	}
+	if block.Type != PEMPublicKeyType {
+		return PublicKey{}, fmt.Errorf("invalid key: unexpected PEM block type %q", block.Type)
+	}
+	return parsePublicKey(block.Bytes)
+}
+
+// NewPrivateKeyFromBase64 creates a new key from a standard base64 string, such as a WireGuard private key. Like
+// NewPrivateKey both the 64 byte and the 32 byte private-only forms are accepted.
+func NewPrivateKeyFromBase64(str string) (PrivateKey, error) {
+	bs, err := base64.StdEncoding.DecodeString(str)
+	if err != nil {
+		return PrivateKey{}, err
+	}
+	return parsePrivateKey(bs)
+}
+
+// Base64String returns the standard base64 encoded private scalar. Only the 32 byte private half is encoded, which
+// is the format WireGuard uses for private keys.
+func (key PrivateKey) Base64String() string {
+	return base64.StdEncoding.EncodeToString(key[:KeySize])
+}
+
+// NewPublicKeyFromBase64 creates a new key from a standard base64 string, such as a WireGuard public key.
+func NewPublicKeyFromBase64(str string) (PublicKey, error) {
+	bs, err := base64.StdEncoding.DecodeString(str)
+	if err != nil {
+		return PublicKey{}, err
+	}
+	return parsePublicKey(bs)
+}
+
+// Base64String returns the standard base64 encoded public key.
+func (key PublicKey) Base64String() string {
+	return base64.StdEncoding.EncodeToString(key[:])
+}
+
+// NewPublicKeyFromBase64URL creates a new key from an unpadded URL-safe base64 string, as returned by
+// Base64URLString.
+func NewPublicKeyFromBase64URL(str string) (PublicKey, error) {
+	bs, err := base64.RawURLEncoding.DecodeString(str)
+	if err != nil {
+		return PublicKey{}, err
+	}
+	return parsePublicKey(bs)
+}
+
+// Base64URLString returns the public key as unpadded URL-safe base64, which can be embedded in a URL without
+// escaping.
+func (key PublicKey) Base64URLString() string {
+	return base64.RawURLEncoding.EncodeToString(key[:])
+}
+
+// base32Encoding is the standard base32 alphabet without padding.
+var base32Encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// NewPublicKeyFromBase32 creates a new key from an unpadded base32 string, as returned by Base32String. Decoding is
+// case-insensitive.
+func NewPublicKeyFromBase32(str string) (PublicKey, error) {
+	bs, err := base32Encoding.DecodeString(strings.ToUpper(str))
+	if err != nil {
+		return PublicKey{}, err
+	}
+	return parsePublicKey(bs)
+}
+
+// Base32String returns the public key as unpadded standard base32. Unlike base58 the encoding is case-insensitive,
+// which makes it easier to read out or scan from a QR code.
+func (key PublicKey) Base32String() string {
+	return base32Encoding.EncodeToString(key[:])
+}
@@ -0,0 +1,181 @@
+package crypt
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/nacl/box"
+)
+
+// fileNoncePrefixSize is the size of the random nonce prefix of an encrypted file. The remainder of each chunk's
+// nonce is a big-endian chunk counter followed by a byte flagging the final chunk.
+const fileNoncePrefixSize = NonceSize - 8 - 1
+
+// ErrChunkOrder indicates that the chunks of an encrypted file were reordered, dropped or truncated.
+var ErrChunkOrder = errors.New("chunk out of sequence or missing")
+
+// EncryptFile encrypts everything read from src for the peer public key and writes it to dst.
+//
+// The output starts with the sender's public key and a random nonce prefix. The data is then sealed in chunks of
+// StreamChunkSize bytes, each written as a length-prefixed frame. The nonce of every chunk is derived from the
+// prefix, a chunk counter and a flag marking the final chunk, so DecryptFile detects reordered, dropped and
+// appended chunks as well as truncation.
+func (key PrivateKey) EncryptFile(peer PublicKey, dst io.Writer, src io.Reader) error {
+	var priv [KeySize]byte
+	copy(priv[:], key[:KeySize])
+
+	var pub [KeySize]byte
+	copy(pub[:], peer[:])
+
+	var shared [KeySize]byte
+	box.Precompute(&shared, &pub, &priv)
+
+	var nonce Nonce
+	if _, err := io.ReadFull(randReader, nonce[:fileNoncePrefixSize]); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	header := make([]byte, 0, KeySize+fileNoncePrefixSize)
+	header = append(header, key[KeySize:]...)
+	header = append(header, nonce[:fileNoncePrefixSize]...)
+	if _, err := dst.Write(header); err != nil {
+		return err
+	}
+
+	chunk, err := readChunk(src, make([]byte, StreamChunkSize))
+	if err != nil {
+		return err
+	}
+	next := make([]byte, StreamChunkSize)
+	frame := make([]byte, 0, streamFrameLengthSize+StreamChunkSize+box.Overhead)
+	for counter := uint64(0); ; counter++ {
+		final := len(chunk) < StreamChunkSize
+		if !final {
+			// a full chunk is only known to be the last once the next read comes up empty
+			next, err = readChunk(src, next[:StreamChunkSize])
+			if err != nil {
+				return err
+			}
+			final = len(next) == 0
+		}
+
+		fileChunkNonce(&nonce, counter, final)
+		frame = append(frame[:0], 0, 0, 0, 0)
+		frame = box.SealAfterPrecomputation(frame, chunk, nonce.ptr(), &shared)
+		binary.BigEndian.PutUint32(frame, uint32(len(frame)-streamFrameLengthSize))
+		if _, err := dst.Write(frame); err != nil {
+			return err
+		}
+
+		if final {
+			return nil
+		}
+		chunk, next = next, chunk
+	}
+}
+
+// DecryptFile decrypts a file produced by EncryptFile from src and writes the plaintext to dst, returning the
+// sender's public key.
+//
+// Every chunk is authenticated before it is written, but if an error is returned the chunks written so far must be
+// discarded: ErrChunkOrder means the file was truncated or its chunks reordered.
+func (key PrivateKey) DecryptFile(dst io.Writer, src io.Reader) (PublicKey, error) {
+	header := make([]byte, KeySize+fileNoncePrefixSize)
+	if _, err := io.ReadFull(src, header); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return PublicKey{}, decryptFailed(fmt.Errorf("invalid file: expected header: %w", err))
+	}
+
+	var sender PublicKey
+	copy(sender[:], header)
+	if err := sender.Validate(); err != nil {
+		return sender, decryptFailed(fmt.Errorf("invalid file: %w", err))
+	}
+
+	var nonce Nonce
+	copy(nonce[:], header[KeySize:])
+
+	var priv [KeySize]byte
+	copy(priv[:], key[:KeySize])
+
+	var pub [KeySize]byte
+	copy(pub[:], sender[:])
+
+	var shared [KeySize]byte
+	box.Precompute(&shared, &pub, &priv)
+
+	frame := make([]byte, StreamChunkSize+box.Overhead)
+	plaintext := make([]byte, 0, StreamChunkSize)
+	for counter := uint64(0); ; counter++ {
+		var length [streamFrameLengthSize]byte
+		if _, err := io.ReadFull(src, length[:]); err != nil {
+			if err == io.EOF {
+				return sender, decryptFailed(fmt.Errorf("invalid file: missing final chunk: %w", ErrChunkOrder))
+			}
+			return sender, decryptFailed(fmt.Errorf("invalid file: expected chunk: %w", err))
+		}
+
+		n := binary.BigEndian.Uint32(length[:])
+		if n < box.Overhead || n > StreamChunkSize+box.Overhead {
+			return sender, decryptFailed(fmt.Errorf("invalid file: invalid chunk length %d", n))
+		}
+		if _, err := io.ReadFull(src, frame[:n]); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return sender, decryptFailed(fmt.Errorf("invalid file: expected chunk: %w", err))
+		}
+
+		// try the chunk as a regular chunk first and as the final chunk second
+		final := false
+		fileChunkNonce(&nonce, counter, final)
+		opened, ok := box.OpenAfterPrecomputation(plaintext[:0], frame[:n], nonce.ptr(), &shared)
+		if !ok {
+			final = true
+			fileChunkNonce(&nonce, counter, final)
+			opened, ok = box.OpenAfterPrecomputation(plaintext[:0], frame[:n], nonce.ptr(), &shared)
+		}
+		if !ok {
+			return sender, decryptFailed(fmt.Errorf("invalid file: chunk %d: %w", counter, ErrChunkOrder))
+		}
+
+		if _, err := dst.Write(opened); err != nil {
+			return sender, err
+		}
+
+		if final {
+			var extra [1]byte
+			switch _, err := io.ReadFull(src, extra[:]); err {
+			case io.EOF:
+				return sender, nil
+			case nil:
+				return sender, decryptFailed(fmt.Errorf("invalid file: data after final chunk: %w", ErrChunkOrder))
+			default:
+				return sender, err
+			}
+		}
+	}
+}
+
+// fileChunkNonce sets the counter and final flag of the nonce for a chunk.
+func fileChunkNonce(nonce *Nonce, counter uint64, final bool) {
+	binary.BigEndian.PutUint64(nonce[fileNoncePrefixSize:], counter)
+	if final {
+		nonce[NonceSize-1] = 1
+	} else {
+		nonce[NonceSize-1] = 0
+	}
+}
+
+// readChunk reads up to len(buf) bytes from r, returning fewer only at the end of r.
+func readChunk(r io.Reader, buf []byte) ([]byte, error) {
+	n, err := io.ReadFull(r, buf)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = nil
+	}
+	return buf[:n], err
+}
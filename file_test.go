@@ -0,0 +1,80 @@
+package crypt
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// splitFileFrames splits an encrypted file into its header and its frames.
+func splitFileFrames(data []byte) (header []byte, frames [][]byte) {
+	header, data = data[:KeySize+fileNoncePrefixSize], data[KeySize+fileNoncePrefixSize:]
+	for len(data) > 0 {
+		n := streamFrameLengthSize + int(binary.BigEndian.Uint32(data))
+		frames = append(frames, data[:n])
+		data = data[n:]
+	}
+	return header, frames
+}
+
+func TestEncryptFile(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	for _, size := range []int{0, 1, StreamChunkSize, StreamChunkSize*3 + 5} {
+		t.Run(fmt.Sprint(size), func(t *testing.T) {
+			msg := make([]byte, size)
+			_, err := io.ReadFull(rand.Reader, msg)
+			assert.NoError(t, err)
+
+			var encrypted bytes.Buffer
+			assert.NoError(t, k1.EncryptFile(k2.PublicKey(), &encrypted, bytes.NewReader(msg)))
+
+			var decrypted bytes.Buffer
+			sender, err := k2.DecryptFile(&decrypted, &encrypted)
+			if assert.NoError(t, err) {
+				assert.Equal(t, k1.PublicKey(), sender)
+				assert.True(t, bytes.Equal(msg, decrypted.Bytes()))
+			}
+		})
+	}
+}
+
+func TestDecryptFileTampered(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	msg := make([]byte, StreamChunkSize*3+5)
+	var encrypted bytes.Buffer
+	assert.NoError(t, k1.EncryptFile(k2.PublicKey(), &encrypted, bytes.NewReader(msg)))
+	header, frames := splitFileFrames(encrypted.Bytes())
+	assert.Len(t, frames, 4)
+
+	join := func(frames ...[]byte) io.Reader {
+		return bytes.NewReader(bytes.Join(append([][]byte{header}, frames...), nil))
+	}
+
+	tests := map[string]io.Reader{
+		"Reordered":   join(frames[1], frames[0], frames[2], frames[3]),
+		"Dropped":     join(frames[0], frames[2], frames[3]),
+		"MissingLast": join(frames[0], frames[1], frames[2]),
+		"Appended":    join(frames[0], frames[1], frames[2], frames[3], frames[3]),
+	}
+	for name, r := range tests {
+		t.Run(name, func(t *testing.T) {
+			var decrypted bytes.Buffer
+			_, err := k2.DecryptFile(&decrypted, r)
+			assert.True(t, errors.Is(err, ErrChunkOrder), "%v", err)
+		})
+	}
+}
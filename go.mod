@@ -3,7 +3,14 @@ module github.com/rtctunnel/crypt
 go 1.13
 
 require (
+	filippo.io/edwards25519 v1.0.0
+	github.com/BurntSushi/toml v0.3.1
+	github.com/fxamacker/cbor/v2 v2.5.0
 	github.com/mr-tron/base58 v1.1.3
-	github.com/stretchr/testify v1.4.0
-	golang.org/x/crypto v0.0.0-20191206172530-e9b2fee46413
+	github.com/stretchr/testify v1.6.1
+	github.com/tyler-smith/go-bip39 v1.1.0
+	github.com/vmihailenco/msgpack/v5 v5.3.5
+	golang.org/x/crypto v0.0.0-20200622213623-75b288015ac9
+	gopkg.in/yaml.v2 v2.2.2
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -0,0 +1,61 @@
+package crypt
+
+import (
+	"crypto/sha256"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// Messages start with a header made up of two magic bytes followed by a byte identifying the message type.
+const (
+	// HeaderSize is the size of a message header in bytes
+	HeaderSize = len(headerMagic) + 1
+
+	// MessageTypeBox identifies a message produced by Encrypt.
+	MessageTypeBox byte = 1
+	// MessageTypeCompressed identifies a message produced by EncryptCompressed.
+	MessageTypeCompressed byte = 2
+	// MessageTypePadded identifies a message produced by EncryptPadded.
+	MessageTypePadded byte = 3
+	// MessageTypeTimestamped identifies a message produced by EncryptTimestamped.
+	MessageTypeTimestamped byte = 4
+)
+
+var headerMagic = [2]byte{'R', 'T'}
+
+// appendHeader appends the header for the message type to dst.
+func appendHeader(dst []byte, typ byte) []byte {
+	dst = append(dst, headerMagic[:]...)
+	return append(dst, typ)
+}
+
+// parseHeader parses the header of a message, returning its type and the body following it. ok is false if the
+// message doesn't start with a header.
+func parseHeader(data []byte) (typ byte, body []byte, ok bool) {
+	if len(data) < HeaderSize || data[0] != headerMagic[0] || data[1] != headerMagic[1] {
+		return 0, data, false
+	}
+	return data[len(headerMagic)], data[HeaderSize:], true
+}
+
+// messageTypeKeyInfo is the HKDF label of the keys messages other than MessageTypeBox are sealed with.
+var messageTypeKeyInfo = []byte("rtctunnel/crypt message type")
+
+// messageKey returns the key messages of the type are sealed with. Messages of MessageTypeBox, like messages without
+// a header, are sealed with the shared key itself, as by crypto_box. Every other type is sealed with a key derived
+// from the shared key and the type, so a message whose header was changed to another type fails to open.
+func messageKey(shared SharedKey, typ byte) [KeySize]byte {
+	if typ == MessageTypeBox {
+		return shared
+	}
+
+	info := make([]byte, 0, len(messageTypeKeyInfo)+1)
+	info = append(info, messageTypeKeyInfo...)
+	info = append(info, typ)
+
+	var key [KeySize]byte
+	// HKDF only fails when asked for more than 255 hashes worth of output
+	_, _ = io.ReadFull(hkdf.New(sha256.New, shared[:], nil, info), key[:])
+	return key
+}
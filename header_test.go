@@ -0,0 +1,68 @@
+package crypt
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mr-tron/base58"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeader(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	msg := []byte("Hello World")
+
+	encrypted := k1.Encrypt(k2.PublicKey(), msg)
+	typ, body, ok := parseHeader(encrypted)
+	if assert.True(t, ok) {
+		assert.Equal(t, MessageTypeBox, typ)
+		assert.Equal(t, k1.PublicKey().String(), base58.Encode(body[:KeySize]))
+	}
+
+	t.Run("Legacy", func(t *testing.T) {
+		legacy := encrypted[HeaderSize:]
+		pub, decrypted, err := k2.Decrypt(legacy)
+		if assert.NoError(t, err) {
+			assert.Equal(t, k1.PublicKey(), pub)
+			assert.Equal(t, msg, decrypted)
+		}
+	})
+	t.Run("UnknownVersion", func(t *testing.T) {
+		unknown := append([]byte(nil), encrypted...)
+		unknown[HeaderSize-1] = 0xff
+		_, _, err := k2.Decrypt(unknown)
+		assert.True(t, errors.Is(err, ErrUnknownVersion))
+		assert.EqualError(t, err, "invalid message: unknown message version: 255")
+	})
+	t.Run("TypeChanged", func(t *testing.T) {
+		messages := map[byte][]byte{
+			MessageTypeBox:         encrypted,
+			MessageTypeCompressed:  k1.EncryptCompressed(k2.PublicKey(), msg),
+			MessageTypePadded:      k1.EncryptPadded(k2.PublicKey(), msg, 16),
+			MessageTypeTimestamped: k1.EncryptTimestamped(k2.PublicKey(), msg),
+		}
+		for from, message := range messages {
+			for to := range messages {
+				if from == to {
+					continue
+				}
+				changed := append([]byte(nil), message...)
+				changed[HeaderSize-1] = to
+				_, _, err := k2.Decrypt(changed)
+				assert.Error(t, err, "%d to %d", from, to)
+				_, _, err = k2.DecryptTimestamped(changed, time.Hour)
+				assert.Error(t, err, "%d to %d", from, to)
+			}
+			if from != MessageTypeBox {
+				// nor can the header be stripped to turn a message into a legacy one
+				_, _, err := k2.Decrypt(message[HeaderSize:])
+				assert.True(t, errors.Is(err, ErrOpenFailed), "%d", from)
+			}
+		}
+	})
+}
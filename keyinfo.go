@@ -0,0 +1,22 @@
+package crypt
+
+import (
+	"time"
+)
+
+// KeyInfo is non-secret metadata about a public key: when it was created and an optional label.
+type KeyInfo struct {
+	PublicKey PublicKey `json:"public_key" yaml:"public_key"`
+	Created   time.Time `json:"created" yaml:"created"`
+	Label     string    `json:"label,omitempty" yaml:"label,omitempty"`
+}
+
+// NewKeyInfo creates a new KeyInfo for the public key, created at the current time as returned by Now.
+func NewKeyInfo(pub PublicKey, label string) KeyInfo {
+	return KeyInfo{
+		PublicKey: pub,
+		// drop the monotonic clock reading, it doesn't survive marshaling
+		Created: Now().UTC().Round(0),
+		Label:   label,
+	}
+}
@@ -0,0 +1,66 @@
+package crypt
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestKeyInfo(t *testing.T) {
+	k, err := Generate()
+	assert.NoError(t, err)
+
+	before := time.Now()
+	info := NewKeyInfo(k.PublicKey(), "laptop")
+	assert.Equal(t, k.PublicKey(), info.PublicKey)
+	assert.Equal(t, "laptop", info.Label)
+	assert.False(t, info.Created.Before(before.Truncate(time.Second)))
+
+	info.Created = time.Date(2020, 7, 1, 12, 30, 15, 0, time.UTC)
+
+	t.Run("JSON", func(t *testing.T) {
+		bs, err := json.Marshal(info)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"public_key":"`+k.PublicKey().String()+`","created":"2020-07-01T12:30:15Z","label":"laptop"}`, string(bs))
+
+		var decoded KeyInfo
+		if assert.NoError(t, json.Unmarshal(bs, &decoded)) {
+			assert.Equal(t, info, decoded)
+		}
+
+		bs, err = json.Marshal(KeyInfo{PublicKey: k.PublicKey(), Created: info.Created})
+		assert.NoError(t, err)
+		assert.NotContains(t, string(bs), "label")
+	})
+	t.Run("YAML", func(t *testing.T) {
+		bs, err := yaml.Marshal(info)
+		assert.NoError(t, err)
+		assert.Equal(t, "public_key: "+k.PublicKey().String()+"\ncreated: 2020-07-01T12:30:15Z\nlabel: laptop\n", string(bs))
+
+		var decoded KeyInfo
+		if assert.NoError(t, yaml.Unmarshal(bs, &decoded)) {
+			assert.Equal(t, info, decoded)
+		}
+	})
+	t.Run("Clock", func(t *testing.T) {
+		defer func() { Now = time.Now }()
+		Now = func() time.Time { return time.Date(2020, 7, 1, 14, 30, 15, 0, time.FixedZone("CEST", 2*60*60)) }
+
+		info := NewKeyInfo(k.PublicKey(), "")
+		assert.Equal(t, time.Date(2020, 7, 1, 12, 30, 15, 0, time.UTC), info.Created)
+	})
+	t.Run("Now", func(t *testing.T) {
+		info := NewKeyInfo(k.PublicKey(), "")
+		bs, err := json.Marshal(info)
+		assert.NoError(t, err)
+
+		var decoded KeyInfo
+		if assert.NoError(t, json.Unmarshal(bs, &decoded)) {
+			assert.True(t, info.Created.Equal(decoded.Created))
+			assert.Equal(t, info, decoded)
+		}
+	})
+}
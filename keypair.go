@@ -0,0 +1,58 @@
+package crypt
+
+// KeyPair is a private key together with its public key.
+//
+// When marshaled only the private key is stored, the public key is derived from it when unmarshaling.
+type KeyPair struct {
+	Private PrivateKey
+	Public  PublicKey
+}
+
+// GenerateKeyPair generates a new KeyPair.
+func GenerateKeyPair() (KeyPair, error) {
+	priv, err := Generate()
+	if err != nil {
+		return KeyPair{}, err
+	}
+	return NewKeyPair(priv), nil
+}
+
+// NewKeyPair creates a new KeyPair from a private key.
+func NewKeyPair(priv PrivateKey) KeyPair {
+	return KeyPair{
+		Private: priv,
+		Public:  priv.PublicKey(),
+	}
+}
+
+// MarshalJSON marshals the key pair as the private key's base58 JSON string.
+func (kp KeyPair) MarshalJSON() ([]byte, error) {
+	return kp.Private.MarshalJSON()
+}
+
+// UnmarshalJSON unmarshals the key pair from a private key's base58 JSON string.
+func (kp *KeyPair) UnmarshalJSON(data []byte) error {
+	var priv PrivateKey
+	err := priv.UnmarshalJSON(data)
+	if err != nil {
+		return err
+	}
+	*kp = NewKeyPair(priv)
+	return nil
+}
+
+// MarshalYAML marshals the key pair as the private key for a YAML file.
+func (kp KeyPair) MarshalYAML() (interface{}, error) {
+	return kp.Private.MarshalYAML()
+}
+
+// UnmarshalYAML unmarshals the key pair from a private key in a YAML file.
+func (kp *KeyPair) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var priv PrivateKey
+	err := priv.UnmarshalYAML(unmarshal)
+	if err != nil {
+		return err
+	}
+	*kp = NewKeyPair(priv)
+	return nil
+}
@@ -0,0 +1,40 @@
+package crypt
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestKeyPair(t *testing.T) {
+	kp, err := GenerateKeyPair()
+	assert.NoError(t, err)
+	assert.Equal(t, kp.Private.PublicKey(), kp.Public)
+
+	type config struct {
+		Identity KeyPair `json:"identity" yaml:"identity"`
+	}
+
+	t.Run("JSON", func(t *testing.T) {
+		bs, err := json.Marshal(config{Identity: kp})
+		assert.NoError(t, err)
+		assert.Equal(t, `{"identity":"`+kp.Private.String()+`"}`, string(bs))
+
+		var decoded config
+		if assert.NoError(t, json.Unmarshal(bs, &decoded)) {
+			assert.Equal(t, kp, decoded.Identity)
+		}
+	})
+	t.Run("YAML", func(t *testing.T) {
+		bs, err := yaml.Marshal(config{Identity: kp})
+		assert.NoError(t, err)
+		assert.Equal(t, "identity: "+kp.Private.String()+"\n", string(bs))
+
+		var decoded config
+		if assert.NoError(t, yaml.Unmarshal(bs, &decoded)) {
+			assert.Equal(t, kp, decoded.Identity)
+		}
+	})
+}
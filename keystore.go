@@ -0,0 +1,51 @@
+package crypt
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// A KeyStore loads private keys by name.
+type KeyStore interface {
+	LoadPrivate(name string) (PrivateKey, error)
+}
+
+// FileKeyStore is a KeyStore which reads base58 encoded keys from files. The name is a path relative to Dir.
+type FileKeyStore struct {
+	Dir string
+}
+
+// LoadPrivate reads the private key from the named file. Surrounding whitespace, such as a trailing newline, is
+// ignored.
+func (ks FileKeyStore) LoadPrivate(name string) (PrivateKey, error) {
+	bs, err := ioutil.ReadFile(filepath.Join(ks.Dir, name))
+	if err != nil {
+		return PrivateKey{}, fmt.Errorf("failed to load key %s: %w", name, err)
+	}
+	key, err := NewPrivateKey(string(bs))
+	if err != nil {
+		return key, fmt.Errorf("failed to load key %s: %w", name, err)
+	}
+	return key, nil
+}
+
+// EnvKeyStore is a KeyStore which reads base58 encoded keys from environment variables. The name is the name of
+// the variable with Prefix prepended.
+type EnvKeyStore struct {
+	Prefix string
+}
+
+// LoadPrivate reads the private key from the named environment variable. Surrounding whitespace is ignored.
+func (ks EnvKeyStore) LoadPrivate(name string) (PrivateKey, error) {
+	value, ok := os.LookupEnv(ks.Prefix + name)
+	if !ok {
+		return PrivateKey{}, fmt.Errorf("failed to load key %s: environment variable %s is not set", name, ks.Prefix+name)
+	}
+	key, err := NewPrivateKey(value)
+	if err != nil {
+		return key, fmt.Errorf("failed to load key %s: %w", name, err)
+	}
+	return key, nil
+}
@@ -0,0 +1,56 @@
+package crypt
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileKeyStore(t *testing.T) {
+	k, err := Generate()
+	assert.NoError(t, err)
+
+	dir, err := ioutil.TempDir("", "crypt")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "key"), []byte(k.String()+"\n"), 0600))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "invalid"), []byte("not a key\n"), 0600))
+
+	var ks KeyStore = FileKeyStore{Dir: dir}
+
+	loaded, err := ks.LoadPrivate("key")
+	if assert.NoError(t, err) {
+		assert.Equal(t, k, loaded)
+	}
+
+	_, err = ks.LoadPrivate("invalid")
+	assert.Error(t, err)
+
+	_, err = ks.LoadPrivate("missing")
+	assert.True(t, os.IsNotExist(errors.Unwrap(err)))
+}
+
+func TestEnvKeyStore(t *testing.T) {
+	k, err := Generate()
+	assert.NoError(t, err)
+
+	defer os.Unsetenv("CRYPT_TEST_KEY")
+	assert.NoError(t, os.Setenv("CRYPT_TEST_KEY", " "+k.String()+"\r\n"))
+
+	var ks KeyStore = EnvKeyStore{Prefix: "CRYPT_TEST_"}
+
+	loaded, err := ks.LoadPrivate("KEY")
+	if assert.NoError(t, err) {
+		assert.Equal(t, k, loaded)
+	}
+
+	_, err = ks.LoadPrivate("MISSING")
+	assert.EqualError(t, err, "failed to load key MISSING: environment variable CRYPT_TEST_MISSING is not set")
+}
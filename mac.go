@@ -0,0 +1,31 @@
+package crypt
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+)
+
+// macInfo is the HKDF label of the subkey used for MACs, distinct from any label used for encryption.
+var macInfo = []byte("rtctunnel/crypt mac")
+
+// MAC returns an HMAC-SHA256 tag authenticating data between the private key and the peer public key. The HMAC key
+// is a subkey of the shared key, so the same shared key can safely be used for encryption as well. Both sides
+// compute the same tag, which the peer checks via VerifyMAC.
+func (key PrivateKey) MAC(peer PublicKey, data []byte) []byte {
+	mac := hmac.New(sha256.New, key.macKey(peer))
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// VerifyMAC reports whether tag is a valid MAC of data between the private key and the peer public key. The
+// comparison is constant time.
+func (key PrivateKey) VerifyMAC(peer PublicKey, data, tag []byte) bool {
+	return hmac.Equal(key.MAC(peer, data), tag)
+}
+
+// macKey derives the HMAC key for the peer public key.
+func (key PrivateKey) macKey(peer PublicKey) []byte {
+	// the length is valid, so deriving can't fail
+	subkey, _ := key.DeriveSubkey(peer, macInfo, sha256.Size)
+	return subkey
+}
@@ -0,0 +1,41 @@
+package crypt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMAC(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	data := []byte("frame header")
+
+	tag := k1.MAC(k2.PublicKey(), data)
+	assert.Len(t, tag, 32)
+	assert.Equal(t, tag, k2.MAC(k1.PublicKey(), data), "both sides should compute the same tag")
+	assert.True(t, k2.VerifyMAC(k1.PublicKey(), data, tag))
+
+	// the MAC key is independent of the encryption key
+	shared := k1.Precompute(k2.PublicKey())
+	assert.NotEqual(t, shared[:], k1.macKey(k2.PublicKey()))
+
+	for i := 0; i < len(data)*8; i++ {
+		flipped := append([]byte(nil), data...)
+		flipped[i/8] ^= 1 << uint(i%8)
+		assert.False(t, k2.VerifyMAC(k1.PublicKey(), flipped, tag), "bit %d of the data", i)
+	}
+	for i := 0; i < len(tag)*8; i++ {
+		flipped := append([]byte(nil), tag...)
+		flipped[i/8] ^= 1 << uint(i%8)
+		assert.False(t, k2.VerifyMAC(k1.PublicKey(), data, flipped), "bit %d of the tag", i)
+	}
+	assert.False(t, k2.VerifyMAC(k1.PublicKey(), data, tag[:16]))
+
+	k3, err := Generate()
+	assert.NoError(t, err)
+	assert.False(t, k2.VerifyMAC(k3.PublicKey(), data, tag))
+}
@@ -0,0 +1,60 @@
+package crypt
+
+import (
+	"fmt"
+)
+
+// Message is the structure of a message produced by Encrypt or one of its variants, as returned by ParseMessage.
+type Message struct {
+	// Type is the message type declared in the header, or 0 for a message without a header as produced by earlier
+	// versions of Encrypt.
+	Type byte
+	// SenderPublicKey is the public key of the sender.
+	SenderPublicKey PublicKey
+	// Nonce is the nonce the box was sealed with.
+	Nonce Nonce
+	// Ciphertext is the sealed box. It refers to the parsed data rather than a copy.
+	Ciphertext []byte
+}
+
+// ParseMessage parses the structure of a message without decrypting it. It performs the same length checks as
+// Decrypt does for the type declared in the header.
+//
+// Unlike Decrypt, ParseMessage doesn't fall back to the format without a header when the header can't be parsed. A
+// message starting with a header of an unknown type is rejected with ErrUnknownVersion, and one too short for its
+// declared type with ErrShortMessage or ErrMissingNonce, even though it may be a message without a header whose
+// sender key happens to start with the header's bytes, which Decrypt still decrypts.
+func ParseMessage(data []byte) (*Message, error) {
+	typ, body, ok := parseHeader(data)
+	if ok {
+		switch typ {
+		case MessageTypeBox, MessageTypeCompressed, MessageTypePadded, MessageTypeTimestamped:
+		default:
+			return nil, fmt.Errorf("invalid message: %w: %d", ErrUnknownVersion, typ)
+		}
+	}
+
+	msg, err := parseBox(body)
+	if err != nil {
+		return nil, err
+	}
+	msg.Type = typ
+	return &msg, nil
+}
+
+// parseBox parses a message without a header: the peer's public key, the nonce and the sealed box.
+func parseBox(data []byte) (Message, error) {
+	var msg Message
+	if len(data) < KeySize {
+		return msg, fmt.Errorf("invalid message: expected public key: %w", ErrShortMessage)
+	}
+	copy(msg.SenderPublicKey[:], data)
+	data = data[KeySize:]
+
+	if len(data) < NonceSize {
+		return msg, fmt.Errorf("invalid message: expected nonce: %w", ErrMissingNonce)
+	}
+	copy(msg.Nonce[:], data)
+	msg.Ciphertext = data[NonceSize:]
+	return msg, nil
+}
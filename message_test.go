@@ -0,0 +1,67 @@
+package crypt
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMessage(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	nonce, err := generateNonce()
+	assert.NoError(t, err)
+	encrypted := k1.EncryptWithNonce(k2.PublicKey(), nonce, []byte("Hello World"))
+
+	msg, err := ParseMessage(encrypted)
+	if assert.NoError(t, err) {
+		assert.Equal(t, MessageTypeBox, msg.Type)
+		assert.Equal(t, k1.PublicKey(), msg.SenderPublicKey)
+		assert.Equal(t, nonce, msg.Nonce)
+		assert.Equal(t, encrypted[HeaderSize+KeySize+NonceSize:], msg.Ciphertext)
+		assert.Len(t, msg.Ciphertext, len("Hello World")+16)
+	}
+
+	t.Run("Variants", func(t *testing.T) {
+		for _, encrypted := range [][]byte{
+			k1.EncryptCompressed(k2.PublicKey(), []byte("Hello World")),
+			k1.EncryptTimestamped(k2.PublicKey(), []byte("Hello World")),
+		} {
+			msg, err := ParseMessage(encrypted)
+			if assert.NoError(t, err) {
+				assert.Equal(t, encrypted[2], msg.Type)
+				assert.Equal(t, k1.PublicKey(), msg.SenderPublicKey)
+			}
+		}
+	})
+	t.Run("Legacy", func(t *testing.T) {
+		msg, err := ParseMessage(encrypted[HeaderSize:])
+		if assert.NoError(t, err) {
+			assert.Equal(t, byte(0), msg.Type)
+			assert.Equal(t, k1.PublicKey(), msg.SenderPublicKey)
+			assert.Equal(t, nonce, msg.Nonce)
+		}
+	})
+	t.Run("Malformed", func(t *testing.T) {
+		_, err := ParseMessage(encrypted[:HeaderSize+KeySize-1])
+		assert.True(t, errors.Is(err, ErrShortMessage))
+		_, err = ParseMessage(encrypted[:HeaderSize+KeySize+NonceSize-1])
+		assert.True(t, errors.Is(err, ErrMissingNonce))
+
+		unknown := append([]byte(nil), encrypted...)
+		unknown[2] = 0xff
+		_, err = ParseMessage(unknown)
+		assert.True(t, errors.Is(err, ErrUnknownVersion))
+
+		// the same checks are made by Decrypt
+		for _, data := range [][]byte{encrypted[:HeaderSize+KeySize-1], encrypted[:HeaderSize+KeySize+NonceSize-1]} {
+			_, parseErr := ParseMessage(data)
+			_, _, decryptErr := k2.Decrypt(data)
+			assert.True(t, errors.Is(decryptErr, errors.Unwrap(parseErr)), decryptErr)
+		}
+	})
+}
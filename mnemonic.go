@@ -0,0 +1,40 @@
+package crypt
+
+import (
+	"fmt"
+
+	"github.com/tyler-smith/go-bip39"
+)
+
+// GenerateFromMnemonic generates a PrivateKey from a BIP-39 mnemonic of any valid length and an optional
+// passphrase. The mnemonic's checksum is verified, then the standard BIP-39 seed is derived from the mnemonic and
+// the passphrase and used as the seed for GenerateFromSeed, so other BIP-39 tools restore the same key.
+//
+// The key is not the one the mnemonic was created from by Mnemonic, use NewPrivateKeyFromMnemonic to restore that.
+func GenerateFromMnemonic(mnemonic, passphrase string) (PrivateKey, error) {
+	if _, err := bip39.EntropyFromMnemonic(mnemonic); err != nil {
+		return PrivateKey{}, fmt.Errorf("invalid mnemonic: %w", err)
+	}
+	return GenerateFromSeed(bip39.NewSeed(mnemonic, passphrase))
+}
+
+// NewPrivateKeyFromMnemonic restores the PrivateKey a 24 word BIP-39 mnemonic was created from by Mnemonic, using
+// its entropy as the private scalar. The mnemonic's checksum is verified.
+func NewPrivateKeyFromMnemonic(mnemonic string) (PrivateKey, error) {
+	entropy, err := bip39.EntropyFromMnemonic(mnemonic)
+	if err != nil {
+		return PrivateKey{}, fmt.Errorf("invalid mnemonic: %w", err)
+	}
+	if len(entropy) != KeySize {
+		return PrivateKey{}, fmt.Errorf("invalid mnemonic: expected %d bytes of entropy, got %d", KeySize, len(entropy))
+	}
+	return newPrivateKeyFromScalar(entropy)
+}
+
+// Mnemonic returns the private scalar as a 24 word BIP-39 mnemonic.
+//
+// The key can only be restored from it via NewPrivateKeyFromMnemonic. GenerateFromMnemonic derives a different,
+// unrelated key from the same words, with or without a passphrase.
+func (key PrivateKey) Mnemonic() (string, error) {
+	return bip39.NewMnemonic(key[:KeySize])
+}
@@ -0,0 +1,84 @@
+package crypt
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMnemonic(t *testing.T) {
+	k, err := Generate()
+	assert.NoError(t, err)
+
+	mnemonic, err := k.Mnemonic()
+	assert.NoError(t, err)
+	assert.Len(t, strings.Fields(mnemonic), 24)
+
+	restored, err := NewPrivateKeyFromMnemonic(mnemonic)
+	if assert.NoError(t, err) {
+		assert.Equal(t, k, restored)
+	}
+
+	// the BIP-39 seed derivation doesn't restore the key
+	seeded, err := GenerateFromMnemonic(mnemonic, "")
+	assert.NoError(t, err)
+	assert.NotEqual(t, k, seeded)
+	assert.NotEqual(t, k.PublicKey(), seeded.PublicKey())
+
+	withPassphrase, err := GenerateFromMnemonic(mnemonic, "TREZOR")
+	assert.NoError(t, err)
+	assert.NotEqual(t, k, withPassphrase)
+	assert.NotEqual(t, seeded, withPassphrase)
+}
+
+func TestGenerateFromMnemonic(t *testing.T) {
+	// test vectors from the BIP-39 reference implementation
+	const mnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon " +
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon art"
+	const short = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+	for _, tc := range []struct {
+		mnemonic, passphrase, seed string
+	}{
+		{mnemonic, "", "408b285c123836004f4b8842c89324c1f01382450c0d439af345ba7fc49acf70" +
+			"5489c6fc77dbd4e3dc1dd8cc6bc9f043db8ada1e243c4a0eafb290d399480840"},
+		{mnemonic, "TREZOR", "bda85446c68413707090a52022edd26a1c9462295029f2e60cd7c4f2bbd30971" +
+			"70af7a4d73245cafa9c3cca8d561a7c3de6f5d4a10be8ed2a5e608d68f92fcc8"},
+		{short, "", "5eb00bbddcf069084889a8ab9155568165f5c453ccb85e70811aaed6f6da5fc1" +
+			"9a5ac40b389cd370d086206dec8aa6c43daea6690f20ad3d8d48b2d2ce9e38e4"},
+	} {
+		k, err := GenerateFromMnemonic(tc.mnemonic, tc.passphrase)
+		assert.NoError(t, err)
+		seed, _ := hex.DecodeString(tc.seed)
+		expected, err := GenerateFromSeed(seed)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, k)
+	}
+
+	k, err := GenerateFromMnemonic(mnemonic, "TREZOR")
+	assert.NoError(t, err)
+	assert.Equal(t, "78a70674176978ec8a275065c9a27c931dd6003c92f79c0643246eaf34838a60"+
+		"cc314c2e0cf8a0b60d1378372c778a23c559a8a12ebe6570fb03d0c531021e6f", k.HexString())
+
+	_, err = GenerateFromMnemonic(strings.Replace(mnemonic, "art", "abandon", 1), "")
+	assert.Error(t, err, "invalid checksum")
+}
+
+func TestNewPrivateKeyFromMnemonic(t *testing.T) {
+	const mnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon " +
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon art"
+
+	k, err := NewPrivateKeyFromMnemonic(mnemonic)
+	assert.NoError(t, err)
+	assert.Equal(t, make([]byte, KeySize), k[:KeySize])
+	assert.Equal(t, "2fe57da347cd62431528daac5fbb290730fff684afc4cfc2ed90995f58cb3b74", k.PublicKey().HexString())
+
+	_, err = NewPrivateKeyFromMnemonic(strings.Replace(mnemonic, "art", "abandon", 1))
+	assert.Error(t, err, "invalid checksum")
+
+	_, err = NewPrivateKeyFromMnemonic("abandon abandon abandon abandon abandon abandon abandon abandon abandon " +
+		"abandon abandon about")
+	assert.Error(t, err, "12 words")
+}
@@ -0,0 +1,33 @@
+package crypt
+
+import (
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// EncodeMsgpack implements msgpack.CustomEncoder. The raw 64 bytes of the key are encoded as a msgpack bin.
+func (key PrivateKey) EncodeMsgpack(enc *msgpack.Encoder) error {
+	return enc.EncodeBytes(key[:])
+}
+
+// DecodeMsgpack implements msgpack.CustomDecoder.
+func (key *PrivateKey) DecodeMsgpack(dec *msgpack.Decoder) error {
+	bs, err := dec.DecodeBytes()
+	if err != nil {
+		return err
+	}
+	return key.UnmarshalBinary(bs)
+}
+
+// EncodeMsgpack implements msgpack.CustomEncoder. The raw 32 bytes of the key are encoded as a msgpack bin.
+func (key PublicKey) EncodeMsgpack(enc *msgpack.Encoder) error {
+	return enc.EncodeBytes(key[:])
+}
+
+// DecodeMsgpack implements msgpack.CustomDecoder.
+func (key *PublicKey) DecodeMsgpack(dec *msgpack.Decoder) error {
+	bs, err := dec.DecodeBytes()
+	if err != nil {
+		return err
+	}
+	return key.UnmarshalBinary(bs)
+}
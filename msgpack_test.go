@@ -0,0 +1,46 @@
+package crypt
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestMsgpack(t *testing.T) {
+	priv, err := Generate()
+	assert.NoError(t, err)
+	pub := priv.PublicKey()
+
+	type record struct {
+		Private PrivateKey
+		Public  PublicKey
+	}
+
+	bs, err := msgpack.Marshal(record{Private: priv, Public: pub})
+	assert.NoError(t, err)
+
+	var decoded record
+	if assert.NoError(t, msgpack.Unmarshal(bs, &decoded)) {
+		assert.Equal(t, priv, decoded.Private)
+		assert.Equal(t, pub, decoded.Public)
+	}
+
+	// keys are encoded as bin 8 with a one byte length
+	bs, err = msgpack.Marshal(pub)
+	assert.NoError(t, err)
+	assert.Equal(t, append([]byte{0xc4, KeySize}, pub[:]...), bs)
+
+	bs, err = msgpack.Marshal(pub[:KeySize-1])
+	assert.NoError(t, err)
+	var decodedPub PublicKey
+	assert.Error(t, msgpack.Unmarshal(bs, &decodedPub))
+
+	corrupted := priv
+	corrupted[KeySize] ^= 0xff
+	bs, err = msgpack.Marshal(corrupted)
+	assert.NoError(t, err)
+	var decodedPriv PrivateKey
+	assert.True(t, errors.Is(msgpack.Unmarshal(bs, &decodedPriv), ErrKeyMismatch))
+}
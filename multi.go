@@ -0,0 +1,231 @@
+package crypt
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/nacl/box"
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+// wrappedKeySize is the size of a data key and payload digest sealed for a single recipient.
+const wrappedKeySize = NonceSize + KeySize + sha256.Size + box.Overhead
+
+// EncryptMulti encrypts data using the private key for several peers at once. The data is encrypted once under a
+// random data key, and the data key is sealed for each of the peers together with the SHA-256 digest of the
+// encrypted data. Any one of the peers can decrypt the result via DecryptMulti. As the digest binds the data to
+// the sender, a recipient can't reuse the data key to pass off different data as the sender's to the other peers.
+//
+// The result is the sender's public key, the number of recipients, the sealed data keys and finally the nonce and
+// ciphertext of the data. The recipients' public keys are not included.
+func (key PrivateKey) EncryptMulti(peers []PublicKey, data []byte) ([]byte, error) {
+	if len(peers) == 0 {
+		return nil, errors.New("no recipients")
+	}
+
+	var priv [KeySize]byte
+	copy(priv[:], key[:KeySize])
+
+	var dataKey [KeySize]byte
+	if _, err := io.ReadFull(randReader, dataKey[:]); err != nil {
+		return nil, err
+	}
+
+	nonce, err := generateNonce()
+	if err != nil {
+		return nil, err
+	}
+	payload := make([]byte, 0, NonceSize+len(data)+secretbox.Overhead)
+	payload = append(payload, nonce[:]...)
+	payload = secretbox.Seal(payload, data, nonce.ptr(), &dataKey)
+
+	digest := sha256.Sum256(payload)
+	wrapped := make([]byte, 0, KeySize+sha256.Size)
+	wrapped = append(wrapped, dataKey[:]...)
+	wrapped = append(wrapped, digest[:]...)
+
+	result := make([]byte, 0, KeySize+4+len(peers)*wrappedKeySize+len(payload))
+	result = append(result, key[KeySize:]...)
+	result = append(result, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(result[KeySize:], uint32(len(peers)))
+
+	for _, peer := range peers {
+		var pub [KeySize]byte
+		copy(pub[:], peer[:])
+
+		nonce, err := generateNonce()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, nonce[:]...)
+		result = box.Seal(result, wrapped, nonce.ptr(), &pub, &priv)
+	}
+
+	return append(result, payload...), nil
+}
+
+// DecryptMulti decrypts data that was encrypted via EncryptMulti. The peer's public key is returned along with the
+// data. Data that doesn't match the digest sealed with the data key fails with ErrOpenFailed.
+func (key PrivateKey) DecryptMulti(data []byte) (PublicKey, []byte, error) {
+	var priv [KeySize]byte
+	copy(priv[:], key[:KeySize])
+
+	if len(data) < KeySize {
+		return PublicKey{}, nil, decryptFailed(fmt.Errorf("invalid message: expected public key: %w", ErrShortMessage))
+	}
+
+	var pub [KeySize]byte
+	copy(pub[:], data[:])
+	data = data[KeySize:]
+
+	if err := PublicKey(pub).Validate(); err != nil {
+		return pub, nil, decryptFailed(fmt.Errorf("invalid message: %w", err))
+	}
+
+	if len(data) < 4 {
+		return pub, nil, decryptFailed(fmt.Errorf("invalid message: expected recipient count: %w", ErrShortMessage))
+	}
+	count := binary.BigEndian.Uint32(data)
+	data = data[4:]
+
+	if uint64(len(data)) < uint64(count)*wrappedKeySize {
+		return pub, nil, decryptFailed(fmt.Errorf("invalid message: expected recipients: %w", ErrShortMessage))
+	}
+	wrapped := data[:int(count)*wrappedKeySize]
+	data = data[int(count)*wrappedKeySize:]
+
+	var shared [KeySize]byte
+	box.Precompute(&shared, &pub, &priv)
+
+	var dataKey [KeySize]byte
+	var sealedDigest []byte
+	for ; len(wrapped) > 0; wrapped = wrapped[wrappedKeySize:] {
+		var nonce [NonceSize]byte
+		copy(nonce[:], wrapped)
+
+		opened, ok := box.OpenAfterPrecomputation(nil, wrapped[NonceSize:wrappedKeySize], &nonce, &shared)
+		if ok && len(opened) == KeySize+sha256.Size {
+			copy(dataKey[:], opened)
+			sealedDigest = opened[KeySize:]
+			break
+		}
+	}
+	if sealedDigest == nil {
+		return pub, nil, decryptFailed(fmt.Errorf("invalid message: not a recipient: %w", ErrOpenFailed))
+	}
+
+	// the data key on its own doesn't authenticate the sender, any recipient knows it
+	digest := sha256.Sum256(data)
+	if subtle.ConstantTimeCompare(digest[:], sealedDigest) != 1 {
+		return pub, nil, decryptFailed(fmt.Errorf("invalid message: data doesn't match the sealed digest: %w", ErrOpenFailed))
+	}
+
+	if len(data) < NonceSize {
+		return pub, nil, decryptFailed(fmt.Errorf("invalid message: expected nonce: %w", ErrMissingNonce))
+	}
+
+	var nonce [NonceSize]byte
+	copy(nonce[:], data[:])
+	data = data[NonceSize:]
+
+	opened, ok := secretbox.Open(nil, data, &nonce, &dataKey)
+	if !ok {
+		return pub, nil, decryptFailed(fmt.Errorf("invalid message: %w", ErrOpenFailed))
+	}
+
+	return pub, opened, nil
+}
+
+// anonymousWrappedKeySize is the size of a data key sealed anonymously for a single recipient.
+const anonymousWrappedKeySize = KeySize + box.AnonymousOverhead
+
+// BroadcastSeal encrypts data for several recipients without a sender key. Like EncryptMulti, the data is
+// encrypted once under a random data key, but the data key is sealed for each of the recipients via SealAnonymous.
+// Any one of the recipients can decrypt the result via BroadcastOpen.
+//
+// The result is the number of recipients, the sealed data keys and finally the nonce and ciphertext of the data.
+func BroadcastSeal(recipients []PublicKey, data []byte) ([]byte, error) {
+	if len(recipients) == 0 {
+		return nil, errors.New("no recipients")
+	}
+
+	var dataKey [KeySize]byte
+	if _, err := io.ReadFull(randReader, dataKey[:]); err != nil {
+		return nil, err
+	}
+
+	result := make([]byte, 4, 4+len(recipients)*anonymousWrappedKeySize+NonceSize+len(data)+secretbox.Overhead)
+	binary.BigEndian.PutUint32(result, uint32(len(recipients)))
+
+	for _, recipient := range recipients {
+		var pub [KeySize]byte
+		copy(pub[:], recipient[:])
+
+		var err error
+		result, err = box.SealAnonymous(result, dataKey[:], &pub, randReader)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	nonce, err := generateNonce()
+	if err != nil {
+		return nil, err
+	}
+	result = append(result, nonce[:]...)
+	result = secretbox.Seal(result, data, nonce.ptr(), &dataKey)
+	return result, nil
+}
+
+// BroadcastOpen decrypts data that was encrypted via BroadcastSeal.
+func (key PrivateKey) BroadcastOpen(data []byte) ([]byte, error) {
+	var priv [KeySize]byte
+	copy(priv[:], key[:KeySize])
+
+	var pub [KeySize]byte
+	copy(pub[:], key[KeySize:])
+
+	if len(data) < 4 {
+		return nil, decryptFailed(fmt.Errorf("invalid message: expected recipient count: %w", ErrShortMessage))
+	}
+	count := binary.BigEndian.Uint32(data)
+	data = data[4:]
+
+	if uint64(len(data)) < uint64(count)*anonymousWrappedKeySize {
+		return nil, decryptFailed(fmt.Errorf("invalid message: expected recipients: %w", ErrShortMessage))
+	}
+	wrapped := data[:int(count)*anonymousWrappedKeySize]
+	data = data[int(count)*anonymousWrappedKeySize:]
+
+	var dataKey [KeySize]byte
+	found := false
+	for ; len(wrapped) > 0; wrapped = wrapped[anonymousWrappedKeySize:] {
+		opened, ok := box.OpenAnonymous(dataKey[:0], wrapped[:anonymousWrappedKeySize], &pub, &priv)
+		if ok && len(opened) == KeySize {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, decryptFailed(fmt.Errorf("invalid message: not a recipient: %w", ErrOpenFailed))
+	}
+
+	if len(data) < NonceSize {
+		return nil, decryptFailed(fmt.Errorf("invalid message: expected nonce: %w", ErrMissingNonce))
+	}
+
+	var nonce [NonceSize]byte
+	copy(nonce[:], data[:])
+	data = data[NonceSize:]
+
+	opened, ok := secretbox.Open(nil, data, &nonce, &dataKey)
+	if !ok {
+		return nil, decryptFailed(fmt.Errorf("invalid message: %w", ErrOpenFailed))
+	}
+
+	return opened, nil
+}
@@ -0,0 +1,160 @@
+package crypt
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/nacl/box"
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+func TestEncryptMulti(t *testing.T) {
+	sender, err := Generate()
+	assert.NoError(t, err)
+
+	msg := []byte("Hello World")
+
+	for _, n := range []int{1, 2, 50} {
+		t.Run(fmt.Sprint(n), func(t *testing.T) {
+			recipients := make([]PrivateKey, n)
+			peers := make([]PublicKey, n)
+			for i := range recipients {
+				recipients[i], err = Generate()
+				assert.NoError(t, err)
+				peers[i] = recipients[i].PublicKey()
+			}
+
+			encrypted, err := sender.EncryptMulti(peers, msg)
+			assert.NoError(t, err)
+
+			for _, recipient := range recipients {
+				pub, decrypted, err := recipient.DecryptMulti(encrypted)
+				if assert.NoError(t, err) {
+					assert.Equal(t, sender.PublicKey(), pub)
+					assert.Equal(t, msg, decrypted)
+				}
+			}
+
+			outsider, err := Generate()
+			assert.NoError(t, err)
+			_, _, err = outsider.DecryptMulti(encrypted)
+			assert.True(t, errors.Is(err, ErrOpenFailed))
+		})
+	}
+}
+
+func TestEncryptMultiInvalid(t *testing.T) {
+	sender, err := Generate()
+	assert.NoError(t, err)
+	recipient, err := Generate()
+	assert.NoError(t, err)
+
+	_, err = sender.EncryptMulti(nil, []byte("Hello World"))
+	assert.Error(t, err)
+
+	encrypted, err := sender.EncryptMulti([]PublicKey{recipient.PublicKey()}, []byte("Hello World"))
+	assert.NoError(t, err)
+
+	_, _, err = recipient.DecryptMulti(encrypted[:KeySize+4+wrappedKeySize-1])
+	assert.True(t, errors.Is(err, ErrShortMessage))
+
+	encrypted[len(encrypted)-1] ^= 0xff
+	_, _, err = recipient.DecryptMulti(encrypted)
+	assert.True(t, errors.Is(err, ErrOpenFailed))
+}
+
+func TestEncryptMultiForgedByRecipient(t *testing.T) {
+	sender, err := Generate()
+	assert.NoError(t, err)
+	mallory, err := Generate()
+	assert.NoError(t, err)
+	victim, err := Generate()
+	assert.NoError(t, err)
+
+	encrypted, err := sender.EncryptMulti([]PublicKey{mallory.PublicKey(), victim.PublicKey()}, []byte("Hello World"))
+	assert.NoError(t, err)
+
+	// mallory, a legitimate recipient, opens its wrapped data key and encrypts other data under it
+	wrapped := encrypted[KeySize+4:]
+	var nonce [NonceSize]byte
+	copy(nonce[:], wrapped)
+	shared := mallory.Precompute(sender.PublicKey())
+	opened, ok := box.OpenAfterPrecomputation(nil, wrapped[NonceSize:wrappedKeySize], &nonce, (*[KeySize]byte)(&shared))
+	if !assert.True(t, ok) {
+		return
+	}
+	var dataKey [KeySize]byte
+	copy(dataKey[:], opened)
+
+	forged := append([]byte(nil), encrypted[:KeySize+4+2*wrappedKeySize]...)
+	forged = append(forged, nonce[:]...)
+	forged = secretbox.Seal(forged, []byte("transfer 100 to mallory"), &nonce, &dataKey)
+
+	_, _, err = victim.DecryptMulti(forged)
+	assert.True(t, errors.Is(err, ErrOpenFailed), "%v", err)
+
+	// the original still decrypts
+	_, decrypted, err := victim.DecryptMulti(encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("Hello World"), decrypted)
+}
+
+func TestBroadcast(t *testing.T) {
+	msg := []byte("Hello World")
+
+	recipients := make([]PrivateKey, 3)
+	peers := make([]PublicKey, len(recipients))
+	for i := range recipients {
+		var err error
+		recipients[i], err = Generate()
+		assert.NoError(t, err)
+		peers[i] = recipients[i].PublicKey()
+	}
+
+	encrypted, err := BroadcastSeal(peers, msg)
+	assert.NoError(t, err)
+	assert.Len(t, encrypted, 4+len(peers)*anonymousWrappedKeySize+NonceSize+len(msg)+16)
+
+	for _, recipient := range recipients {
+		decrypted, err := recipient.BroadcastOpen(encrypted)
+		if assert.NoError(t, err) {
+			assert.Equal(t, msg, decrypted)
+		}
+	}
+
+	outsider, err := Generate()
+	assert.NoError(t, err)
+	_, err = outsider.BroadcastOpen(encrypted)
+	assert.True(t, errors.Is(err, ErrOpenFailed))
+
+	t.Run("RemovedRecipient", func(t *testing.T) {
+		// drop the second recipient's wrapped key
+		removed := make([]byte, 0, len(encrypted)-anonymousWrappedKeySize)
+		removed = append(removed, 0, 0, 0, 2)
+		removed = append(removed, encrypted[4:4+anonymousWrappedKeySize]...)
+		removed = append(removed, encrypted[4+2*anonymousWrappedKeySize:]...)
+
+		for i, recipient := range recipients {
+			decrypted, err := recipient.BroadcastOpen(removed)
+			if i == 1 {
+				assert.True(t, errors.Is(err, ErrOpenFailed))
+			} else if assert.NoError(t, err) {
+				assert.Equal(t, msg, decrypted)
+			}
+		}
+	})
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := BroadcastSeal(nil, msg)
+		assert.Error(t, err)
+
+		_, err = recipients[0].BroadcastOpen(encrypted[:4+anonymousWrappedKeySize-1])
+		assert.True(t, errors.Is(err, ErrShortMessage))
+
+		tampered := append([]byte(nil), encrypted...)
+		tampered[len(tampered)-1] ^= 0xff
+		_, err = recipients[0].BroadcastOpen(tampered)
+		assert.True(t, errors.Is(err, ErrOpenFailed))
+	})
+}
@@ -0,0 +1,154 @@
+package crypt
+
+import (
+	"container/list"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"golang.org/x/crypto/nacl/box"
+)
+
+var (
+	// ErrNonceReused indicates that a nonce has been used before.
+	ErrNonceReused = errors.New("nonce reused")
+	// ErrNonceExhausted indicates that a SessionNonces has handed out all of its nonces.
+	ErrNonceExhausted = errors.New("nonces exhausted")
+)
+
+// sessionNoncePrefixSize is the size of the random prefix of session nonces. The rest of the nonce is the counter.
+const sessionNoncePrefixSize = NonceSize - 8
+
+// NonceTracker remembers recently used nonces to detect nonce reuse. Only the most recently recorded nonces are kept,
+// so a tracker is a safety net rather than a guarantee: a nonce reused after more than Size other nonces goes
+// undetected.
+//
+// A NonceTracker is safe for concurrent use.
+type NonceTracker struct {
+	size int
+
+	mu    sync.Mutex
+	order *list.List
+	seen  map[Nonce]*list.Element
+}
+
+// NewNonceTracker creates a new NonceTracker which remembers the last size nonces.
+func NewNonceTracker(size int) *NonceTracker {
+	if size < 1 {
+		size = 1
+	}
+	return &NonceTracker{
+		size:  size,
+		order: list.New(),
+		seen:  make(map[Nonce]*list.Element, size),
+	}
+}
+
+// Size returns the number of nonces the tracker remembers.
+func (t *NonceTracker) Size() int {
+	return t.size
+}
+
+// Check records the nonce and returns ErrNonceReused if it was seen before.
+func (t *NonceTracker) Check(nonce Nonce) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// Detected reuses don't refresh a nonce: that would evict nonces recorded more recently and create false
+	// negatives within the window.
+	if _, ok := t.seen[nonce]; ok {
+		return ErrNonceReused
+	}
+
+	t.seen[nonce] = t.order.PushFront(nonce)
+	if t.order.Len() > t.size {
+		oldest := t.order.Back()
+		t.order.Remove(oldest)
+		delete(t.seen, oldest.Value.(Nonce))
+	}
+	return nil
+}
+
+// EncryptChecked is like EncryptWithNonce, but consults the tracker first and returns ErrNonceReused rather than
+// sealing the data if the nonce was used before.
+func (key PrivateKey) EncryptChecked(tracker *NonceTracker, peersPublicKey PublicKey, nonce Nonce, data []byte) ([]byte, error) {
+	if err := tracker.Check(nonce); err != nil {
+		return nil, err
+	}
+	return key.EncryptWithNonce(peersPublicKey, nonce, data), nil
+}
+
+// SessionNonces generates unique nonces for a single session without reading randomness for every message: each
+// nonce is a random 16 byte prefix, chosen once, followed by a big-endian 64 bit counter. After 2^64 nonces New
+// returns ErrNonceExhausted and a new SessionNonces must be created.
+//
+// A SessionNonces is safe for concurrent use.
+type SessionNonces struct {
+	prefix [sessionNoncePrefixSize]byte
+
+	mu        sync.Mutex
+	counter   uint64
+	exhausted bool
+}
+
+// NewSessionNonces creates a new SessionNonces with a random prefix.
+func NewSessionNonces() (*SessionNonces, error) {
+	sn := new(SessionNonces)
+	if _, err := io.ReadFull(randReader, sn.prefix[:]); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce prefix: %w", err)
+	}
+	return sn, nil
+}
+
+// New returns the next nonce of the session.
+func (sn *SessionNonces) New() (Nonce, error) {
+	sn.mu.Lock()
+	defer sn.mu.Unlock()
+
+	var nonce Nonce
+	if sn.exhausted {
+		return nonce, ErrNonceExhausted
+	}
+
+	copy(nonce[:], sn.prefix[:])
+	binary.BigEndian.PutUint64(nonce[sessionNoncePrefixSize:], sn.counter)
+	sn.counter++
+	if sn.counter == 0 {
+		sn.exhausted = true
+	}
+	return nonce, nil
+}
+
+// NonceForSequence returns the nonce for a message sequence number: the session base followed by the big-endian
+// sequence number. This is the same layout SessionNonces uses.
+func NonceForSequence(base [16]byte, seq uint64) Nonce {
+	var nonce Nonce
+	copy(nonce[:], base[:])
+	binary.BigEndian.PutUint64(nonce[sessionNoncePrefixSize:], seq)
+	return nonce
+}
+
+// EncryptSeq encrypts data for the peer public key using the nonce for the sequence number. Neither the nonce, the
+// header nor the sender's public key are included in the result: both sides must track the sequence number and the
+// peer decrypts with DecryptSeq using the same base and sequence number.
+//
+// WARNING: a sequence number must never be used twice with the same base and pair of keys.
+func (key PrivateKey) EncryptSeq(peer PublicKey, base [16]byte, seq uint64, data []byte) []byte {
+	var priv [KeySize]byte
+	copy(priv[:], key[:KeySize])
+
+	var pub [KeySize]byte
+	copy(pub[:], peer[:])
+
+	nonce := NonceForSequence(base, seq)
+	return box.Seal(nil, data, nonce.ptr(), &pub, &priv)
+}
+
+// DecryptSeq decrypts data that was encrypted via EncryptSeq by the peer. Decryption fails with ErrOpenFailed if the
+// sequence number doesn't match, so a message that was dropped or reordered is detected by the next message failing
+// to decrypt.
+func (key PrivateKey) DecryptSeq(peer PublicKey, base [16]byte, seq uint64, data []byte) ([]byte, error) {
+	return key.OpenDetached(peer, NonceForSequence(base, seq), data)
+}
@@ -0,0 +1,141 @@
+package crypt
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/nacl/box"
+)
+
+func counterNonce(i uint64) Nonce {
+	var nonce Nonce
+	binary.BigEndian.PutUint64(nonce[NonceSize-8:], i)
+	return nonce
+}
+
+func TestNonceTracker(t *testing.T) {
+	tracker := NewNonceTracker(16)
+	assert.Equal(t, 16, tracker.Size())
+
+	assert.NoError(t, tracker.Check(counterNonce(1)))
+	assert.Equal(t, ErrNonceReused, tracker.Check(counterNonce(1)))
+
+	t.Run("Window", func(t *testing.T) {
+		tracker := NewNonceTracker(16)
+		for i := uint64(0); i < 100; i++ {
+			assert.NoError(t, tracker.Check(counterNonce(i)))
+			// every nonce within the window must still be detected
+			for j := uint64(0); j < 16 && j <= i; j++ {
+				assert.Equal(t, ErrNonceReused, tracker.Check(counterNonce(i-j)), "nonce %d after %d", i-j, i)
+			}
+		}
+		// nonces outside of the window are forgotten
+		assert.NoError(t, tracker.Check(counterNonce(0)))
+	})
+}
+
+func TestEncryptChecked(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	tracker := NewNonceTracker(16)
+	msg := []byte("Hello World")
+
+	encrypted, err := k1.EncryptChecked(tracker, k2.PublicKey(), counterNonce(1), msg)
+	assert.NoError(t, err)
+	_, decrypted, err := k2.Decrypt(encrypted)
+	if assert.NoError(t, err) {
+		assert.Equal(t, msg, decrypted)
+	}
+
+	_, err = k1.EncryptChecked(tracker, k2.PublicKey(), counterNonce(1), msg)
+	assert.Equal(t, ErrNonceReused, err)
+}
+
+func TestSessionNonces(t *testing.T) {
+	sn, err := NewSessionNonces()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	first, err := sn.New()
+	assert.NoError(t, err)
+	for i := uint64(1); i < 100; i++ {
+		nonce, err := sn.New()
+		assert.NoError(t, err)
+		assert.Equal(t, first[:NonceSize-8], nonce[:NonceSize-8], "the prefix should be stable")
+		assert.Equal(t, i, binary.BigEndian.Uint64(nonce[NonceSize-8:]))
+	}
+
+	other, err := NewSessionNonces()
+	assert.NoError(t, err)
+	otherFirst, err := other.New()
+	assert.NoError(t, err)
+	assert.NotEqual(t, first, otherFirst, "sessions should use different prefixes")
+
+	t.Run("Exhausted", func(t *testing.T) {
+		sn, err := NewSessionNonces()
+		assert.NoError(t, err)
+		sn.counter = math.MaxUint64
+
+		nonce, err := sn.New()
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(math.MaxUint64), binary.BigEndian.Uint64(nonce[NonceSize-8:]))
+
+		_, err = sn.New()
+		assert.Equal(t, ErrNonceExhausted, err)
+		_, err = sn.New()
+		assert.Equal(t, ErrNonceExhausted, err)
+	})
+}
+
+func TestNonceForSequence(t *testing.T) {
+	var base [16]byte
+	for i := range base {
+		base[i] = byte(i + 1)
+	}
+
+	nonce := NonceForSequence(base, 0x0102)
+	assert.Equal(t, base[:], nonce[:16])
+	assert.Equal(t, []byte{0, 0, 0, 0, 0, 0, 1, 2}, nonce[16:])
+	assert.Equal(t, nonce, NonceForSequence(base, 0x0102), "the same base and sequence should give the same nonce")
+	assert.NotEqual(t, nonce, NonceForSequence(base, 0x0103))
+}
+
+func TestEncryptSeq(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	var base [16]byte
+	for i := range base {
+		base[i] = byte(i + 1)
+	}
+
+	var msgs [][]byte
+	for seq := uint64(0); seq < 3; seq++ {
+		encrypted := k1.EncryptSeq(k2.PublicKey(), base, seq, []byte("Hello World"))
+		assert.Len(t, encrypted, len("Hello World")+box.Overhead, "the nonce should not be sent")
+		msgs = append(msgs, encrypted)
+	}
+
+	decrypted, err := k2.DecryptSeq(k1.PublicKey(), base, 0, msgs[0])
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Hello World", string(decrypted))
+	}
+
+	// the message with sequence number 1 was dropped
+	_, err = k2.DecryptSeq(k1.PublicKey(), base, 1, msgs[2])
+	assert.True(t, errors.Is(err, ErrOpenFailed), "a gap should be detected")
+
+	decrypted, err = k2.DecryptSeq(k1.PublicKey(), base, 2, msgs[2])
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Hello World", string(decrypted))
+	}
+}
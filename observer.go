@@ -0,0 +1,68 @@
+package crypt
+
+import (
+	"errors"
+)
+
+// An Observer is notified of security relevant events, for example to count them for monitoring. Observers never
+// receive key material or plaintext.
+type Observer interface {
+	// OnDecryptFailure is called when any of the functions decrypting or opening messages, streams, files or
+	// connections fails, once per failed call. The reason is the message of the sentinel error describing the
+	// failure, such as ErrOpenFailed, or the full error message if there is none.
+	OnDecryptFailure(reason string)
+	// OnLowOrderPoint is called when a message is rejected because its sender key is a low-order point. The
+	// decryption failure is reported as well.
+	OnLowOrderPoint()
+}
+
+// observer is notified of events, if set.
+var observer Observer
+
+// SetObserver sets the observer notified of events. Passing nil removes it. SetObserver must not be called
+// concurrently with other functions of the package.
+func SetObserver(o Observer) {
+	observer = o
+}
+
+// decryptFailureReasons are the sentinel errors reported as the reason of a decryption failure.
+var decryptFailureReasons = []error{
+	ErrShortMessage,
+	ErrMissingNonce,
+	ErrLowOrderPoint,
+	ErrOpenFailed,
+	ErrUnknownVersion,
+	ErrDecompressedTooLarge,
+	ErrChunkOrder,
+	ErrMessageExpired,
+	ErrMessageTooLarge,
+	ErrUnexpectedSender,
+	ErrPlaintextTooShort,
+}
+
+// decryptFailed notifies the observer of a failure to decrypt and returns err.
+func decryptFailed(err error) error {
+	notifyDecryptFailure(err)
+	return err
+}
+
+// notifyDecryptFailure notifies the observer of a failure to decrypt.
+func notifyDecryptFailure(err error) {
+	o := observer
+	if o == nil {
+		return
+	}
+
+	if errors.Is(err, ErrLowOrderPoint) {
+		o.OnLowOrderPoint()
+	}
+
+	reason := err.Error()
+	for _, sentinel := range decryptFailureReasons {
+		if errors.Is(err, sentinel) {
+			reason = sentinel.Error()
+			break
+		}
+	}
+	o.OnDecryptFailure(reason)
+}
@@ -0,0 +1,263 @@
+package crypt
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingObserver struct {
+	reasons  []string
+	lowOrder int
+}
+
+func (o *recordingObserver) OnDecryptFailure(reason string) { o.reasons = append(o.reasons, reason) }
+func (o *recordingObserver) OnLowOrderPoint()               { o.lowOrder++ }
+
+func TestObserver(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	msg := []byte("Hello World")
+	encrypted := k1.Encrypt(k2.PublicKey(), msg)
+
+	// without an observer failures are ignored
+	_, _, err = k1.Decrypt(encrypted)
+	assert.Error(t, err)
+
+	o := new(recordingObserver)
+	SetObserver(o)
+	defer SetObserver(nil)
+
+	_, _, err = k2.Decrypt(encrypted)
+	assert.NoError(t, err)
+	assert.Empty(t, o.reasons, "successful decryption should not be reported")
+
+	_, _, err = k1.Decrypt(encrypted)
+	assert.Error(t, err)
+	_, _, err = k2.Decrypt(encrypted[:HeaderSize+KeySize+10])
+	assert.Error(t, err)
+	_, _, err = k2.DecryptAppend(nil, encrypted[:10])
+	assert.Error(t, err)
+
+	lowOrder := append([]byte(nil), encrypted...)
+	zero, _ := hex.DecodeString("e0eb7a7c3b41b8ae1656e3faf19fc46ada098deb9c32b1fd866205165f49b800")
+	copy(lowOrder[HeaderSize:], zero)
+	_, _, err = k2.Decrypt(lowOrder)
+	assert.Error(t, err)
+
+	assert.Equal(t, []string{
+		ErrOpenFailed.Error(),
+		ErrMissingNonce.Error(),
+		ErrShortMessage.Error(),
+		ErrLowOrderPoint.Error(),
+	}, o.reasons)
+	assert.Equal(t, 1, o.lowOrder)
+
+	for _, reason := range o.reasons {
+		assert.NotContains(t, reason, k1.PublicKey().String())
+		assert.NotContains(t, reason, string(msg))
+	}
+}
+
+func TestObserverDecryptPaths(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	msg := []byte("Hello World")
+	zero, _ := hex.DecodeString("e0eb7a7c3b41b8ae1656e3faf19fc46ada098deb9c32b1fd866205165f49b800")
+	withSender := func(data []byte, sender []byte) []byte {
+		data = append([]byte(nil), data...)
+		copy(data, sender)
+		return data
+	}
+	tampered := func(data []byte) []byte {
+		data = append([]byte(nil), data...)
+		data[len(data)-1] ^= 0xff
+		return data
+	}
+
+	multi, err := k1.EncryptMulti([]PublicKey{k2.PublicKey()}, msg)
+	assert.NoError(t, err)
+	broadcast, err := BroadcastSeal([]PublicKey{k2.PublicKey()}, msg)
+	assert.NoError(t, err)
+	anonymous, err := SealAnonymous(k2.PublicKey(), msg)
+	assert.NoError(t, err)
+	chacha, err := k1.EncryptChaCha(k2.PublicKey(), msg, nil)
+	assert.NoError(t, err)
+	var stream bytes.Buffer
+	w := k1.NewEncryptWriter(k2.PublicKey(), &stream)
+	_, err = w.Write(msg)
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+	var file bytes.Buffer
+	assert.NoError(t, k1.EncryptFile(k2.PublicKey(), &file, bytes.NewReader(msg)))
+	nonce, detached := k1.SealDetached(k2.PublicKey(), msg)
+	shared := k1.Precompute(k2.PublicKey())
+	messageKey := NewRatchet(shared).Next()
+
+	readStream := func(key PrivateKey, data []byte) error {
+		_, r, err := key.NewDecryptReader(bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		_, err = ioutil.ReadAll(r)
+		return err
+	}
+
+	for _, tc := range []struct {
+		name     string
+		decrypt  func() error
+		reason   error
+		lowOrder bool
+	}{
+		{"DecryptPadded", func() error {
+			_, _, err := k2.DecryptPadded(tampered(k1.EncryptPadded(k2.PublicKey(), msg, 16)))
+			return err
+		}, ErrOpenFailed, false},
+		{"DecryptBucketed", func() error {
+			_, _, err := k2.DecryptBucketed(k1.Encrypt(k2.PublicKey(), msg))
+			return err
+		}, nil, false},
+		{"DecryptCompressed", func() error {
+			_, _, err := k2.DecryptCompressed(tampered(k1.EncryptCompressed(k2.PublicKey(), msg)))
+			return err
+		}, ErrOpenFailed, false},
+		{"DecryptTimestamped", func() error {
+			_, _, err := k2.DecryptTimestamped(tampered(k1.EncryptTimestamped(k2.PublicKey(), msg)), time.Minute)
+			return err
+		}, ErrOpenFailed, false},
+		{"DecryptSafe", func() error {
+			_, _, err := k2.DecryptSafe(tampered(k1.Encrypt(k2.PublicKey(), msg)))
+			return err
+		}, ErrOpenFailed, false},
+		{"DecryptFrom", func() error {
+			_, err := k2.DecryptFrom(k2.PublicKey(), k1.Encrypt(k2.PublicKey(), msg))
+			return err
+		}, ErrUnexpectedSender, false},
+		{"DecryptString", func() error {
+			_, _, err := k2.DecryptString("0OIl")
+			return err
+		}, nil, false},
+		{"DecryptDataURI", func() error {
+			_, _, err := k2.DecryptDataURI("http://example.com", "text/plain")
+			return err
+		}, nil, false},
+		{"DecryptMulti", func() error {
+			_, _, err := k1.DecryptMulti(multi)
+			return err
+		}, ErrOpenFailed, false},
+		{"DecryptMultiLowOrder", func() error {
+			_, _, err := k2.DecryptMulti(withSender(multi, zero))
+			return err
+		}, ErrLowOrderPoint, true},
+		{"BroadcastOpen", func() error {
+			_, err := k1.BroadcastOpen(broadcast)
+			return err
+		}, ErrOpenFailed, false},
+		{"OpenAnonymous", func() error {
+			_, err := k1.OpenAnonymous(anonymous)
+			return err
+		}, ErrOpenFailed, false},
+		{"DecryptChaCha", func() error {
+			_, err := k2.DecryptChaCha(k1.PublicKey(), chacha, []byte("aad"))
+			return err
+		}, ErrOpenFailed, false},
+		{"DecryptWithAAD", func() error {
+			_, _, err := k2.DecryptWithAAD(k1.EncryptWithAAD(k2.PublicKey(), msg, nil), []byte("aad"))
+			return err
+		}, ErrOpenFailed, false},
+		{"DecryptWithAADLowOrder", func() error {
+			_, _, err := k2.DecryptWithAAD(withSender(k1.EncryptWithAAD(k2.PublicKey(), msg, nil), zero), nil)
+			return err
+		}, ErrLowOrderPoint, true},
+		{"OpenDetached", func() error {
+			_, err := k2.OpenDetached(k1.PublicKey(), nonce, tampered(detached))
+			return err
+		}, ErrOpenFailed, false},
+		{"DecryptWithSender", func() error {
+			_, err := k2.DecryptWithSender(k1.PublicKey(), tampered(k1.EncryptNoSender(k2.PublicKey(), msg)))
+			return err
+		}, ErrOpenFailed, false},
+		{"OpenLibsodium", func() error {
+			_, err := k2.OpenLibsodium(k1.PublicKey(), msg[:10])
+			return err
+		}, ErrMissingNonce, false},
+		{"SharedKeyOpen", func() error {
+			_, err := shared.Open(tampered(shared.Seal(msg)))
+			return err
+		}, ErrOpenFailed, false},
+		{"CipherOpen", func() error {
+			c := k2.NewCipher(k1.PublicKey())
+			_, err := c.Open(nil, tampered(k1.NewCipher(k2.PublicKey()).Seal(nil, msg)))
+			return err
+		}, ErrOpenFailed, false},
+		{"RatchetOpen", func() error {
+			_, err := RatchetOpen(messageKey, tampered(RatchetSeal(messageKey, msg)))
+			return err
+		}, ErrOpenFailed, false},
+		{"DecryptReader", func() error {
+			return readStream(k2, tampered(stream.Bytes()))
+		}, ErrOpenFailed, false},
+		{"DecryptReaderLowOrder", func() error {
+			return readStream(k2, withSender(stream.Bytes(), zero))
+		}, ErrLowOrderPoint, true},
+		{"DecryptFile", func() error {
+			_, err := k2.DecryptFile(ioutil.Discard, bytes.NewReader(tampered(file.Bytes())))
+			return err
+		}, ErrChunkOrder, false},
+		{"WrapConn", func() error {
+			c1, c2 := net.Pipe()
+			defer c1.Close()
+			go func() {
+				defer c2.Close()
+				salt := make([]byte, connSaltSize)
+				if _, err := io.ReadFull(c2, salt); err != nil {
+					return
+				}
+				_, _ = c2.Write(salt)
+				frame := make([]byte, connFrameLengthSize+16+len(msg))
+				binary.BigEndian.PutUint32(frame, uint32(len(frame)-connFrameLengthSize))
+				_, _ = io.ReadFull(rand.Reader, frame[connFrameLengthSize:])
+				_, _ = c2.Write(frame)
+			}()
+			_, err := k2.WrapConn(c1, k1.PublicKey()).Read(make([]byte, 100))
+			return err
+		}, ErrOpenFailed, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			o := new(recordingObserver)
+			SetObserver(o)
+			defer SetObserver(nil)
+
+			err := tc.decrypt()
+			if !assert.Error(t, err) {
+				return
+			}
+			reason := err.Error()
+			if tc.reason != nil {
+				assert.True(t, errors.Is(err, tc.reason), "%v", err)
+				reason = tc.reason.Error()
+			}
+			assert.Equal(t, []string{reason}, o.reasons, "the failure should be reported exactly once")
+			if tc.lowOrder {
+				assert.Equal(t, 1, o.lowOrder)
+			} else {
+				assert.Equal(t, 0, o.lowOrder)
+			}
+		})
+	}
+}
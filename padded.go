@@ -0,0 +1,112 @@
+package crypt
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/nacl/box"
+)
+
+// paddedLengthSize is the size of the plaintext length prefixed to padded data.
+const paddedLengthSize = 4
+
+// EncryptPadded is like Encrypt, but pads the data to hide its exact length. The length of the data is prefixed to
+// it and zeros are appended so the padded plaintext is a multiple of blockSize bytes. Both the length and the
+// padding are sealed, so decoding is exact. The message is marked as padded in its header. A blockSize below 1 is
+// treated as 1.
+func (key PrivateKey) EncryptPadded(peersPublicKey PublicKey, data []byte, blockSize int) []byte {
+	padded := pad(data, blockSize)
+	result := make([]byte, 0, HeaderSize+KeySize+NonceSize+len(padded)+box.Overhead)
+	return key.encryptAppend(result, peersPublicKey, mustGenerateNonce(), MessageTypePadded, padded)
+}
+
+// pad returns the length prefixed data padded to a multiple of blockSize bytes, as sealed in a padded message.
+func pad(data []byte, blockSize int) []byte {
+	if blockSize < 1 {
+		blockSize = 1
+	}
+
+	size := paddedLengthSize + len(data)
+	if rem := size % blockSize; rem != 0 {
+		size += blockSize - rem
+	}
+
+	padded := make([]byte, size)
+	binary.BigEndian.PutUint32(padded, uint32(len(data)))
+	copy(padded[paddedLengthSize:], data)
+	return padded
+}
+
+// bucketedOverhead is the number of bytes a bucketed message adds to the data.
+const bucketedOverhead = HeaderSize + KeySize + NonceSize + paddedLengthSize + box.Overhead
+
+// EncryptBucketed is like EncryptPadded, but rather than padding to a multiple of a block size the whole message is
+// padded to the smallest of the bucket sizes it fits in, so messages only ever have one of a few fixed lengths.
+// ErrMessageTooLarge is returned if the message doesn't fit even the largest bucket.
+func (key PrivateKey) EncryptBucketed(peersPublicKey PublicKey, data []byte, buckets []int) ([]byte, error) {
+	needed := len(data) + bucketedOverhead
+	size, largest := -1, 0
+	for _, bucket := range buckets {
+		if bucket >= needed && (size < 0 || bucket < size) {
+			size = bucket
+		}
+		if bucket > largest {
+			largest = bucket
+		}
+	}
+	if size < 0 {
+		return nil, fmt.Errorf("%w: %d bytes exceed the largest bucket of %d bytes", ErrMessageTooLarge, needed, largest)
+	}
+
+	nonce, err := generateNonce()
+	if err != nil {
+		return nil, err
+	}
+
+	padded := make([]byte, size-bucketedOverhead+paddedLengthSize)
+	binary.BigEndian.PutUint32(padded, uint32(len(data)))
+	copy(padded[paddedLengthSize:], data)
+
+	result := make([]byte, 0, size)
+	return key.encryptAppend(result, peersPublicKey, nonce, MessageTypePadded, padded), nil
+}
+
+// DecryptBucketed decrypts data that was encrypted via EncryptBucketed and strips the padding. Bucketed messages are
+// padded messages, so this is the same as DecryptPadded.
+func (key PrivateKey) DecryptBucketed(data []byte) (PublicKey, []byte, error) {
+	return key.DecryptPadded(data)
+}
+
+// DecryptPadded decrypts data that was encrypted via EncryptPadded and strips the padding. Decrypt handles padded
+// messages as well, DecryptPadded differs in rejecting messages that aren't padded.
+func (key PrivateKey) DecryptPadded(data []byte) (PublicKey, []byte, error) {
+	typ, body, ok := parseHeader(data)
+	if !ok || typ != MessageTypePadded {
+		return PublicKey{}, nil, decryptFailed(errors.New("invalid message: not padded"))
+	}
+	pub, opened, err := key.decryptPadded(nil, body)
+	if err != nil {
+		return pub, nil, decryptFailed(err)
+	}
+	return pub, opened, nil
+}
+
+// decryptPadded decrypts a padded message without its header and strips the padding. The plaintext is appended to
+// dst.
+func (key PrivateKey) decryptPadded(dst, data []byte) (PublicKey, []byte, error) {
+	pub, padded, err := key.decryptBox(nil, MessageTypePadded, data)
+	if err != nil {
+		return pub, nil, err
+	}
+
+	if len(padded) < paddedLengthSize {
+		return pub, nil, errors.New("invalid message: expected length")
+	}
+	n := binary.BigEndian.Uint32(padded)
+	if uint64(n) > uint64(len(padded)-paddedLengthSize) {
+		return pub, nil, fmt.Errorf("invalid message: invalid length %d", n)
+	}
+
+	return pub, append(dst, padded[paddedLengthSize:paddedLengthSize+int(n)]...), nil
+}
@@ -0,0 +1,108 @@
+package crypt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncryptPadded(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	const blockSize = 64
+	overhead := HeaderSize + KeySize + NonceSize + 16
+
+	for _, tc := range []struct {
+		size, padded int
+	}{
+		{0, 64},
+		{59, 64},
+		// exactly one block including the length prefix
+		{60, 64},
+		{61, 128},
+		{200, 256},
+	} {
+		t.Run(fmt.Sprint(tc.size), func(t *testing.T) {
+			msg := make([]byte, tc.size)
+			for i := range msg {
+				msg[i] = byte(i)
+			}
+
+			encrypted := k1.EncryptPadded(k2.PublicKey(), msg, blockSize)
+			assert.Len(t, encrypted, overhead+tc.padded)
+			assert.Equal(t, MessageTypePadded, encrypted[HeaderSize-1])
+
+			pub, decrypted, err := k2.DecryptPadded(encrypted)
+			if assert.NoError(t, err) {
+				assert.Equal(t, k1.PublicKey(), pub)
+				assert.Equal(t, string(msg), string(decrypted))
+			}
+
+			_, decrypted, err = k2.Decrypt(encrypted)
+			if assert.NoError(t, err) {
+				assert.Equal(t, string(msg), string(decrypted), "Decrypt should strip the padding as well")
+			}
+		})
+	}
+
+	// messages of different lengths within a block look the same
+	assert.Equal(t,
+		len(k1.EncryptPadded(k2.PublicKey(), []byte("ls"), blockSize)),
+		len(k1.EncryptPadded(k2.PublicKey(), []byte("shutdown now"), blockSize)))
+
+	encrypted := k1.EncryptPadded(k2.PublicKey(), []byte("Hello World"), 0)
+	assert.Len(t, encrypted, overhead+4+len("Hello World"))
+
+	_, _, err = k2.DecryptPadded(k1.Encrypt(k2.PublicKey(), []byte("Hello World")))
+	assert.EqualError(t, err, "invalid message: not padded")
+
+	// a length beyond the padded plaintext is rejected
+	invalid := k1.encryptAppend(nil, k2.PublicKey(), mustGenerateNonce(), MessageTypePadded, []byte{0, 0, 0, 5, 1, 2})
+	_, _, err = k2.DecryptPadded(invalid)
+	assert.EqualError(t, err, "invalid message: invalid length 5")
+	invalid = k1.encryptAppend(nil, k2.PublicKey(), mustGenerateNonce(), MessageTypePadded, []byte{0, 0})
+	_, _, err = k2.DecryptPadded(invalid)
+	assert.Error(t, err)
+}
+
+func TestEncryptBucketed(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	buckets := []int{4096, 256, 1024}
+	for _, tc := range []struct {
+		size, bucket int
+	}{
+		{0, 256},
+		{256 - bucketedOverhead, 256},
+		{256 - bucketedOverhead + 1, 1024},
+		{900, 1024},
+		{4096 - bucketedOverhead, 4096},
+	} {
+		data := bytes.Repeat([]byte{'x'}, tc.size)
+		encrypted, err := k1.EncryptBucketed(k2.PublicKey(), data, buckets)
+		if assert.NoError(t, err, tc.size) {
+			assert.Len(t, encrypted, tc.bucket, tc.size)
+
+			sender, decrypted, err := k2.DecryptBucketed(encrypted)
+			if assert.NoError(t, err, tc.size) {
+				assert.Equal(t, k1.PublicKey(), sender)
+				assert.Equal(t, string(data), string(decrypted))
+			}
+		}
+	}
+
+	_, err = k1.EncryptBucketed(k2.PublicKey(), make([]byte, 4096-bucketedOverhead+1), buckets)
+	assert.True(t, errors.Is(err, ErrMessageTooLarge))
+	assert.EqualError(t, err, "message too large: 4097 bytes exceed the largest bucket of 4096 bytes")
+	_, err = k1.EncryptBucketed(k2.PublicKey(), nil, nil)
+	assert.True(t, errors.Is(err, ErrMessageTooLarge))
+}
@@ -0,0 +1,57 @@
+package crypt
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Argon2Params are the parameters used to stretch a password with Argon2id.
+type Argon2Params struct {
+	// Time is the number of passes over the memory.
+	Time uint32
+	// Memory is the amount of memory used in KiB.
+	Memory uint32
+	// Threads is the number of threads used.
+	Threads uint8
+}
+
+// DefaultArgon2Params returns the recommended Argon2id parameters from RFC 9106 for memory constrained environments.
+func DefaultArgon2Params() Argon2Params {
+	return Argon2Params{
+		Time:    3,
+		Memory:  64 * 1024,
+		Threads: 4,
+	}
+}
+
+// GenerateFromPassword deterministically generates a PrivateKey from a password. The password and salt are
+// stretched with Argon2id and the result is used as the seed for GenerateFromSeed.
+func GenerateFromPassword(password, salt []byte, params Argon2Params) (PrivateKey, error) {
+	if params.Time == 0 || params.Memory == 0 || params.Threads == 0 {
+		return PrivateKey{}, errors.New("invalid argon2 parameters")
+	}
+
+	seed := argon2.IDKey(password, salt, params.Time, params.Memory, params.Threads, KeySize)
+	return GenerateFromSeed(seed)
+}
+
+// GenerateFromPasswordScrypt deterministically generates a PrivateKey from a password, like GenerateFromPassword,
+// but stretches the password and salt with scrypt instead of Argon2id. N is the CPU/memory cost and must be a power
+// of two greater than 1, r is the block size and p the parallelization. scrypt needs about 128*N*r bytes of memory.
+func GenerateFromPasswordScrypt(password, salt []byte, N, r, p int) (PrivateKey, error) {
+	if N <= 1 || N&(N-1) != 0 {
+		return PrivateKey{}, errors.New("invalid scrypt parameters: N must be a power of two greater than 1")
+	}
+	if r <= 0 || p <= 0 {
+		return PrivateKey{}, errors.New("invalid scrypt parameters: r and p must be positive")
+	}
+
+	seed, err := scrypt.Key(password, salt, N, r, p, KeySize)
+	if err != nil {
+		return PrivateKey{}, fmt.Errorf("invalid scrypt parameters: %w", err)
+	}
+	return GenerateFromSeed(seed)
+}
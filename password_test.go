@@ -0,0 +1,83 @@
+package crypt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateFromPassword(t *testing.T) {
+	password := []byte("correct horse battery staple")
+	salt := []byte("0123456789abcdef")
+	params := Argon2Params{Time: 1, Memory: 1024, Threads: 1}
+
+	k1, err := GenerateFromPassword(password, salt, params)
+	assert.NoError(t, err)
+	k2, err := GenerateFromPassword(password, salt, params)
+	assert.NoError(t, err)
+	assert.Equal(t, k1, k2)
+
+	variations := []struct {
+		name     string
+		password []byte
+		salt     []byte
+		params   Argon2Params
+	}{
+		{"Password", []byte("incorrect horse battery staple"), salt, params},
+		{"Salt", password, []byte("fedcba9876543210"), params},
+		{"Time", password, salt, Argon2Params{Time: 2, Memory: 1024, Threads: 1}},
+		{"Memory", password, salt, Argon2Params{Time: 1, Memory: 2048, Threads: 1}},
+		{"Threads", password, salt, Argon2Params{Time: 1, Memory: 1024, Threads: 2}},
+	}
+	for _, v := range variations {
+		t.Run(v.name, func(t *testing.T) {
+			k, err := GenerateFromPassword(v.password, v.salt, v.params)
+			assert.NoError(t, err)
+			assert.NotEqual(t, k1, k)
+		})
+	}
+
+	_, err = GenerateFromPassword(password, salt, Argon2Params{})
+	assert.Error(t, err)
+}
+
+func TestGenerateFromPasswordScrypt(t *testing.T) {
+	password := []byte("correct horse battery staple")
+	salt := []byte("0123456789abcdef")
+
+	k1, err := GenerateFromPasswordScrypt(password, salt, 1024, 8, 1)
+	assert.NoError(t, err)
+	k2, err := GenerateFromPasswordScrypt(password, salt, 1024, 8, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, k1, k2)
+
+	// scrypt is not interchangeable with argon2
+	k3, err := GenerateFromPassword(password, salt, Argon2Params{Time: 1, Memory: 1024, Threads: 1})
+	assert.NoError(t, err)
+	assert.NotEqual(t, k1, k3)
+
+	variations := []struct {
+		name     string
+		password []byte
+		salt     []byte
+		N, r, p  int
+	}{
+		{"Password", []byte("incorrect horse battery staple"), salt, 1024, 8, 1},
+		{"Salt", password, []byte("fedcba9876543210"), 1024, 8, 1},
+		{"N", password, salt, 2048, 8, 1},
+		{"r", password, salt, 1024, 4, 1},
+		{"p", password, salt, 1024, 8, 2},
+	}
+	for _, v := range variations {
+		t.Run(v.name, func(t *testing.T) {
+			k, err := GenerateFromPasswordScrypt(v.password, v.salt, v.N, v.r, v.p)
+			assert.NoError(t, err)
+			assert.NotEqual(t, k1, k)
+		})
+	}
+
+	for _, params := range [][3]int{{0, 8, 1}, {1, 8, 1}, {1000, 8, 1}, {-1024, 8, 1}, {1024, 0, 1}, {1024, 8, 0}} {
+		_, err = GenerateFromPasswordScrypt(password, salt, params[0], params[1], params[2])
+		assert.Error(t, err, params)
+	}
+}
@@ -0,0 +1,69 @@
+package crypt
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+// ratchetInfo is the HKDF label used to advance a ratchet's chain key.
+var ratchetInfo = []byte("rtctunnel/crypt ratchet")
+
+// Ratchet is a symmetric ratchet providing forward secrecy within a session. Every call to Next derives a one-time
+// message key and replaces the chain key, so keys of earlier messages can't be recovered from the current state.
+// Both sides seed a ratchet from the same shared key and must advance it in lockstep.
+//
+// A Ratchet is not safe for concurrent use.
+type Ratchet struct {
+	chainKey [KeySize]byte
+}
+
+// NewRatchet creates a new Ratchet seeded from the shared key.
+func NewRatchet(shared SharedKey) *Ratchet {
+	return &Ratchet{chainKey: [KeySize]byte(shared)}
+}
+
+// Next advances the ratchet and returns the next message key. The chain key is expanded with HKDF-SHA256 into the
+// new chain key and the message key, and the previous chain key is discarded.
+func (r *Ratchet) Next() (messageKey [32]byte) {
+	var out [2 * KeySize]byte
+	// 64 bytes is well within the HKDF limit, so reading can't fail
+	_, _ = io.ReadFull(hkdf.New(sha256.New, r.chainKey[:], nil, ratchetInfo), out[:])
+
+	copy(r.chainKey[:], out[:KeySize])
+	copy(messageKey[:], out[KeySize:])
+	for i := range out {
+		out[i] = 0
+	}
+	return messageKey
+}
+
+// RatchetSeal encrypts data using a message key returned by Ratchet.Next. The result is the nonce followed by the
+// ciphertext. Each message key must only be used for a single message.
+func RatchetSeal(messageKey [32]byte, data []byte) []byte {
+	nonce := mustGenerateNonce()
+
+	result := make([]byte, 0, len(nonce)+len(data)+secretbox.Overhead)
+	result = append(result, nonce[:]...)
+	return secretbox.Seal(result, data, nonce.ptr(), &messageKey)
+}
+
+// RatchetOpen decrypts data that was sealed via RatchetSeal using the same message key.
+func RatchetOpen(messageKey [32]byte, data []byte) ([]byte, error) {
+	if len(data) < NonceSize {
+		return nil, decryptFailed(fmt.Errorf("invalid message: expected nonce: %w", ErrMissingNonce))
+	}
+
+	var nonce [NonceSize]byte
+	copy(nonce[:], data[:])
+	data = data[NonceSize:]
+
+	opened, ok := secretbox.Open(nil, data, &nonce, &messageKey)
+	if !ok {
+		return nil, decryptFailed(fmt.Errorf("invalid message: %w", ErrOpenFailed))
+	}
+	return opened, nil
+}
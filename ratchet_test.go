@@ -0,0 +1,46 @@
+package crypt
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRatchet(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	r1 := NewRatchet(k1.Precompute(k2.PublicKey()))
+	r2 := NewRatchet(k2.Precompute(k1.PublicKey()))
+
+	seen := map[[32]byte]bool{}
+	var previous [32]byte
+	for i := 0; i < 10; i++ {
+		key1, key2 := r1.Next(), r2.Next()
+		assert.Equal(t, key1, key2, "both sides should derive the same message key")
+		assert.False(t, seen[key1], "message keys should never repeat")
+		seen[key1] = true
+
+		sealed := RatchetSeal(key1, []byte("Hello World"))
+		opened, err := RatchetOpen(key2, sealed)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "Hello World", string(opened))
+		}
+
+		if i > 0 {
+			_, err = RatchetOpen(previous, sealed)
+			assert.True(t, errors.Is(err, ErrOpenFailed), "an old key should not decrypt a new message")
+		}
+		previous = key1
+	}
+
+	// the message keys are independent of the shared key
+	shared := k1.Precompute(k2.PublicKey())
+	assert.False(t, seen[[32]byte(shared)])
+
+	_, err = RatchetOpen(previous, make([]byte, NonceSize-1))
+	assert.True(t, errors.Is(err, ErrMissingNonce))
+}
@@ -0,0 +1,56 @@
+package crypt
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrUnknownPeer indicates that a peer name is not in a PeerRegistry.
+var ErrUnknownPeer = errors.New("unknown peer")
+
+// PeerRegistry maps peer names to their public keys.
+//
+// A PeerRegistry is safe for concurrent use. Lookups only take a read lock, so concurrent encryptions don't block
+// each other.
+type PeerRegistry struct {
+	mu    sync.RWMutex
+	peers map[string]PublicKey
+}
+
+// NewPeerRegistry creates a new, empty PeerRegistry.
+func NewPeerRegistry() *PeerRegistry {
+	return &PeerRegistry{
+		peers: make(map[string]PublicKey),
+	}
+}
+
+// Add adds the peer to the registry, replacing any public key previously added under the same name.
+func (r *PeerRegistry) Add(name string, pub PublicKey) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.peers[name] = pub
+}
+
+// Get returns the public key of the named peer, or ErrUnknownPeer if it was never added.
+func (r *PeerRegistry) Get(name string) (PublicKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	pub, ok := r.peers[name]
+	if !ok {
+		return PublicKey{}, fmt.Errorf("%w: %q", ErrUnknownPeer, name)
+	}
+	return pub, nil
+}
+
+// EncryptTo encrypts data from self for the named peer, like EncryptSafe. ErrUnknownPeer is returned if the peer was
+// never added.
+func (r *PeerRegistry) EncryptTo(self PrivateKey, name string, data []byte) ([]byte, error) {
+	pub, err := r.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return self.EncryptSafe(pub, data)
+}
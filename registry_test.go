@@ -0,0 +1,55 @@
+package crypt
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeerRegistry(t *testing.T) {
+	self, err := Generate()
+	assert.NoError(t, err)
+	alice, err := Generate()
+	assert.NoError(t, err)
+	bob, err := Generate()
+	assert.NoError(t, err)
+
+	registry := NewPeerRegistry()
+	registry.Add("alice", alice.PublicKey())
+	registry.Add("bob", self.PublicKey())
+	registry.Add("bob", bob.PublicKey())
+
+	pub, err := registry.Get("bob")
+	assert.NoError(t, err)
+	assert.Equal(t, bob.PublicKey(), pub)
+
+	encrypted, err := registry.EncryptTo(self, "alice", []byte("Hello World"))
+	assert.NoError(t, err)
+	sender, decrypted, err := alice.Decrypt(encrypted)
+	if assert.NoError(t, err) {
+		assert.Equal(t, self.PublicKey(), sender)
+		assert.Equal(t, "Hello World", string(decrypted))
+	}
+
+	_, err = registry.EncryptTo(self, "carol", []byte("Hello World"))
+	assert.True(t, errors.Is(err, ErrUnknownPeer))
+	assert.EqualError(t, err, `unknown peer: "carol"`)
+
+	t.Run("Concurrent", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					_, err := registry.EncryptTo(self, "alice", []byte("Hello World"))
+					assert.NoError(t, err)
+				}
+			}()
+		}
+		registry.Add("carol", self.PublicKey())
+		wg.Wait()
+	})
+}
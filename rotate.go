@@ -0,0 +1,20 @@
+package crypt
+
+// Reencrypt decrypts data with the old recipient key and encrypts it for the new peer using the new sender key. The
+// plaintext never leaves this function and is wiped once the new message is sealed. Like EncryptSafe an error is
+// returned if the source of randomness fails.
+func Reencrypt(oldRecipient PrivateKey, newSender PrivateKey, newPeer PublicKey, data []byte) ([]byte, error) {
+	_, plaintext, err := oldRecipient.Decrypt(data)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := newSender.EncryptSafe(newPeer, plaintext)
+	for i := range plaintext {
+		plaintext[i] = 0
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,41 @@
+package crypt
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReencrypt(t *testing.T) {
+	sender, err := Generate()
+	assert.NoError(t, err)
+	oldRecipient, err := Generate()
+	assert.NoError(t, err)
+	newRecipient, err := Generate()
+	assert.NoError(t, err)
+
+	msg := []byte("Hello World")
+	encrypted := sender.Encrypt(oldRecipient.PublicKey(), msg)
+
+	// the old recipient re-encrypts the stored message under its own key for the rotated key
+	reencrypted, err := Reencrypt(oldRecipient, oldRecipient, newRecipient.PublicKey(), encrypted)
+	assert.NoError(t, err)
+
+	pub, decrypted, err := newRecipient.Decrypt(reencrypted)
+	if assert.NoError(t, err) {
+		assert.Equal(t, oldRecipient.PublicKey(), pub)
+		assert.Equal(t, msg, decrypted)
+	}
+
+	_, _, err = oldRecipient.Decrypt(reencrypted)
+	assert.Error(t, err)
+
+	_, err = Reencrypt(newRecipient, newRecipient, oldRecipient.PublicKey(), encrypted)
+	assert.Error(t, err)
+
+	defer SetRandReader(nil)
+	SetRandReader(errReader{errors.New("no entropy")})
+	_, err = Reencrypt(oldRecipient, oldRecipient, newRecipient.PublicKey(), encrypted)
+	assert.EqualError(t, err, "failed to generate nonce: no entropy")
+}
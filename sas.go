@@ -0,0 +1,25 @@
+package crypt
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+)
+
+// SAS returns a 6 digit short authentication string for the two public keys. Both peers compute the same string
+// regardless of the order of the keys, so reading it aloud over another channel confirms that neither key was
+// substituted by a man in the middle.
+func SAS(a, b PublicKey) string {
+	if bytes.Compare(a[:], b[:]) > 0 {
+		a, b = b, a
+	}
+
+	h := sha256.New()
+	h.Write([]byte("rtctunnel/crypt SAS"))
+	h.Write(a[:])
+	h.Write(b[:])
+	digest := h.Sum(nil)
+
+	return fmt.Sprintf("%06d", binary.BigEndian.Uint64(digest)%1000000)
+}
@@ -0,0 +1,33 @@
+package crypt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSAS(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	sas := SAS(k1.PublicKey(), k2.PublicKey())
+	assert.Len(t, sas, 6)
+	assert.Regexp(t, "^[0-9]{6}$", sas)
+	assert.Equal(t, sas, SAS(k2.PublicKey(), k1.PublicKey()))
+
+	k3, err := Generate()
+	assert.NoError(t, err)
+	assert.NotEqual(t, sas, SAS(k1.PublicKey(), k3.PublicKey()))
+}
+
+func TestSASVector(t *testing.T) {
+	a, err := NewPublicKey("6W5kPSASbxje1BjWjWbVGe7XvzHEJSuMUqtaWqAusHfs")
+	assert.NoError(t, err)
+	var b PublicKey
+	for i := range b {
+		b[i] = byte(i + 1)
+	}
+	assert.Equal(t, "219109", SAS(a, b))
+}
@@ -0,0 +1,70 @@
+package crypt
+
+import (
+	"fmt"
+
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/nacl/box"
+)
+
+type (
+	// SharedKey is a shared secret precomputed from a private key and a peer's public key.
+	SharedKey [KeySize]byte
+)
+
+// Precompute computes the shared key for the peer public key. Using the shared key to seal and open many messages
+// to the same peer avoids re-deriving the shared secret for each message.
+func (key PrivateKey) Precompute(peer PublicKey) SharedKey {
+	var priv [KeySize]byte
+	copy(priv[:], key[:KeySize])
+
+	var pub [KeySize]byte
+	copy(pub[:], peer[:])
+
+	var shared [KeySize]byte
+	box.Precompute(&shared, &pub, &priv)
+	return SharedKey(shared)
+}
+
+// SharedSecret returns the raw X25519 shared secret with the peer public key, for use with an external key
+// derivation function. The secret must not be used as a key directly. ErrLowOrderPoint is returned if the peer
+// public key is a low-order point, which would result in an all-zero secret.
+func (key PrivateKey) SharedSecret(peer PublicKey) ([]byte, error) {
+	secret, err := curve25519.X25519(key[:KeySize], peer[:])
+	if err != nil {
+		return nil, ErrLowOrderPoint
+	}
+	return secret, nil
+}
+
+// Seal encrypts data using the shared key. The result is the nonce followed by the ciphertext, the same layout
+// Encrypt uses after the header and the sender's public key.
+func (sk SharedKey) Seal(data []byte) []byte {
+	shared := [KeySize]byte(sk)
+
+	nonce := mustGenerateNonce()
+
+	result := make([]byte, 0, len(nonce)+len(data)+box.Overhead)
+	result = append(result, nonce[:]...)
+	return box.SealAfterPrecomputation(result, data, nonce.ptr(), &shared)
+}
+
+// Open decrypts data that was sealed using the shared key.
+func (sk SharedKey) Open(data []byte) ([]byte, error) {
+	shared := [KeySize]byte(sk)
+
+	if len(data) < NonceSize {
+		return nil, decryptFailed(fmt.Errorf("invalid message: expected nonce: %w", ErrMissingNonce))
+	}
+
+	var nonce [NonceSize]byte
+	copy(nonce[:], data[:])
+	data = data[NonceSize:]
+
+	opened, ok := box.OpenAfterPrecomputation(nil, data, &nonce, &shared)
+	if !ok {
+		return nil, decryptFailed(fmt.Errorf("invalid message: %w", ErrOpenFailed))
+	}
+
+	return opened, nil
+}
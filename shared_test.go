@@ -0,0 +1,82 @@
+package crypt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSharedKey(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	s1 := k1.Precompute(k2.PublicKey())
+	s2 := k2.Precompute(k1.PublicKey())
+	assert.Equal(t, s1, s2)
+
+	msg := []byte("Hello World")
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		decrypted, err := s2.Open(s1.Seal(msg))
+		if assert.NoError(t, err) {
+			assert.Equal(t, msg, decrypted)
+		}
+	})
+	t.Run("Encrypt", func(t *testing.T) {
+		encrypted := k1.Encrypt(k2.PublicKey(), msg)
+		decrypted, err := s2.Open(encrypted[HeaderSize+KeySize:])
+		if assert.NoError(t, err) {
+			assert.Equal(t, msg, decrypted)
+		}
+	})
+	t.Run("Decrypt", func(t *testing.T) {
+		sender := k1.PublicKey()
+		encrypted := append(sender[:], s1.Seal(msg)...)
+		pub, decrypted, err := k2.Decrypt(encrypted)
+		if assert.NoError(t, err) {
+			assert.Equal(t, k1.PublicKey(), pub)
+			assert.Equal(t, msg, decrypted)
+		}
+	})
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := s2.Open(make([]byte, NonceSize-1))
+		assert.Error(t, err)
+
+		sealed := s1.Seal(msg)
+		sealed[len(sealed)-1] ^= 0xff
+		_, err = s2.Open(sealed)
+		assert.Error(t, err)
+	})
+}
+
+func BenchmarkSharedKeySeal(b *testing.B) {
+	k1, _ := Generate()
+	k2, _ := Generate()
+	shared := k1.Precompute(k2.PublicKey())
+	msg := make([]byte, 256)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		shared.Seal(msg)
+	}
+}
+
+func TestSharedSecret(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	s1, err := k1.SharedSecret(k2.PublicKey())
+	assert.NoError(t, err)
+	s2, err := k2.SharedSecret(k1.PublicKey())
+	assert.NoError(t, err)
+	assert.Len(t, s1, KeySize)
+	assert.Equal(t, s1, s2)
+
+	_, err = k1.SharedSecret(PublicKey{})
+	assert.Equal(t, ErrLowOrderPoint, err)
+}
@@ -0,0 +1,119 @@
+package crypt
+
+import (
+	"crypto/ed25519"
+	"crypto/sha512"
+
+	"filippo.io/edwards25519"
+	"filippo.io/edwards25519/field"
+)
+
+const (
+	// SignatureSize is the size of a signature in bytes
+	SignatureSize = ed25519.SignatureSize
+)
+
+// hash1Prefix is the XEdDSA hash_1 domain separator: 2^256 - 1 - 1 encoded little endian.
+var hash1Prefix = [32]byte{
+	0xfe, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+}
+
+// Sign signs the message with the private key.
+//
+// Signatures are XEdDSA signatures: the X25519 private scalar is used as an Ed25519 signing key directly, so no
+// separate signing key needs to be stored and the signature can be verified with nothing but the X25519 public key.
+// Unlike the XEdDSA specification the nonce is derived deterministically (as in Ed25519) rather than from random
+// data, verification is unaffected by this.
+func (key PrivateKey) Sign(message []byte) []byte {
+	a, pub := key.edwards()
+
+	h := sha512.New()
+	h.Write(hash1Prefix[:])
+	h.Write(a.Bytes())
+	h.Write(message)
+	r, err := edwards25519.NewScalar().SetUniformBytes(h.Sum(nil))
+	if err != nil {
+		panic(err)
+	}
+	R := new(edwards25519.Point).ScalarBaseMult(r).Bytes()
+
+	h.Reset()
+	h.Write(R)
+	h.Write(pub)
+	h.Write(message)
+	c, err := edwards25519.NewScalar().SetUniformBytes(h.Sum(nil))
+	if err != nil {
+		panic(err)
+	}
+	s := edwards25519.NewScalar().MultiplyAdd(c, a, r)
+
+	sig := make([]byte, 0, SignatureSize)
+	sig = append(sig, R...)
+	sig = append(sig, s.Bytes()...)
+	return sig
+}
+
+// edwards returns the Ed25519 private scalar and public key matching the X25519 private key. The Edwards public key
+// must have a sign bit of zero, since the X25519 public key doesn't encode it. If the sign bit is set the scalar is
+// negated instead.
+func (key PrivateKey) edwards() (*edwards25519.Scalar, []byte) {
+	k, err := edwards25519.NewScalar().SetBytesWithClamping(key[:KeySize])
+	if err != nil {
+		panic(err)
+	}
+
+	pub := new(edwards25519.Point).ScalarBaseMult(k).Bytes()
+	if pub[31]&0x80 != 0 {
+		k.Negate(k)
+		pub[31] &= 0x7f
+	}
+	return k, pub
+}
+
+// Verify reports whether sig is a valid signature of message by the public key.
+func (key PublicKey) Verify(message, sig []byte) bool {
+	if len(sig) != SignatureSize {
+		return false
+	}
+
+	pub, ok := key.edwards()
+	if !ok {
+		return false
+	}
+
+	return ed25519.Verify(pub, message, sig)
+}
+
+// edwardsPoint converts the X25519 public key to the Edwards point with a sign bit of zero.
+func (key PublicKey) edwardsPoint() (*edwards25519.Point, bool) {
+	pub, ok := key.edwards()
+	if !ok {
+		return nil, false
+	}
+	p, err := new(edwards25519.Point).SetBytes(pub)
+	if err != nil {
+		return nil, false
+	}
+	return p, true
+}
+
+// edwards converts the X25519 public key to the Ed25519 public key with a sign bit of zero.
+func (key PublicKey) edwards() (ed25519.PublicKey, bool) {
+	u, err := new(field.Element).SetBytes(key[:])
+	if err != nil {
+		return nil, false
+	}
+	// reject non-canonical encodings
+	if string(u.Bytes()) != string(key[:]) {
+		return nil, false
+	}
+
+	// y = (u - 1) / (u + 1)
+	one := new(field.Element).One()
+	n := new(field.Element).Subtract(u, one)
+	d := new(field.Element).Add(u, one)
+	y := new(field.Element).Multiply(n, d.Invert(d))
+
+	return ed25519.PublicKey(y.Bytes()), true
+}
@@ -0,0 +1,31 @@
+package crypt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSign(t *testing.T) {
+	for i := 0; i < 16; i++ {
+		k, err := Generate()
+		assert.NoError(t, err)
+
+		msg := []byte("config blob")
+		sig := k.Sign(msg)
+		assert.Len(t, sig, SignatureSize)
+		assert.Equal(t, sig, k.Sign(msg), "signatures should be deterministic")
+		assert.True(t, k.PublicKey().Verify(msg, sig))
+
+		assert.False(t, k.PublicKey().Verify([]byte("config blob!"), sig), "tampered message")
+
+		tampered := append([]byte(nil), sig...)
+		tampered[3] ^= 0x01
+		assert.False(t, k.PublicKey().Verify(msg, tampered), "tampered signature")
+		assert.False(t, k.PublicKey().Verify(msg, sig[:SignatureSize-1]), "truncated signature")
+
+		other, err := Generate()
+		assert.NoError(t, err)
+		assert.False(t, other.PublicKey().Verify(msg, sig), "wrong key")
+	}
+}
@@ -0,0 +1,346 @@
+package crypt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/nacl/box"
+)
+
+const (
+	// StreamChunkSize is the maximum number of plaintext bytes sealed in a single stream frame. If MaxMessageSize is
+	// smaller, chunks are limited to MaxMessageSize bytes instead.
+	StreamChunkSize = 64 * 1024
+
+	streamFrameLengthSize = 4
+	streamFlagData        = 0
+	streamFlagFinal       = 1
+)
+
+// maxStreamFrameSize is the largest frame (nonce + sealed flag and chunk) a stream may contain.
+const maxStreamFrameSize = NonceSize + 1 + StreamChunkSize + box.Overhead
+
+type encryptWriter struct {
+	w         io.Writer
+	sender    PublicKey
+	sharedKey [KeySize]byte
+	buf       []byte
+	chunkSize int
+	nonces    *SessionNonces
+	wroteKey  bool
+	closed    bool
+	err       error
+}
+
+// NewEncryptWriter returns a writer which encrypts everything written to it for the peer public key and writes the
+// result to w.
+//
+// The stream starts with the sender's public key. The plaintext is then split into chunks of at most
+// StreamChunkSize bytes and each chunk is sealed and written as a length-prefixed frame. Frame nonces are a random
+// per-stream prefix followed by the frame's sequence number, so the decrypt reader detects dropped, reordered and
+// spliced frames. Close must be called to flush the final chunk and to write the end-of-stream marker, without it
+// the stream will be rejected as truncated on decrypt. Close does not close w.
+func (key PrivateKey) NewEncryptWriter(peer PublicKey, w io.Writer) io.WriteCloser {
+	return key.newEncryptWriter(peer, w)
+}
+
+func (key PrivateKey) newEncryptWriter(peer PublicKey, w io.Writer) *encryptWriter {
+	var priv [KeySize]byte
+	copy(priv[:], key[:KeySize])
+
+	var pub [KeySize]byte
+	copy(pub[:], peer[:])
+
+	chunkSize := maxStreamChunkSize()
+	ew := &encryptWriter{
+		w:         w,
+		sender:    key.PublicKey(),
+		buf:       make([]byte, 0, chunkSize),
+		chunkSize: chunkSize,
+	}
+	// a failure to generate the nonce prefix is reported by the first Write or Close
+	ew.nonces, ew.err = NewSessionNonces()
+	box.Precompute(&ew.sharedKey, &pub, &priv)
+	return ew
+}
+
+// maxStreamChunkSize returns the largest number of plaintext bytes a stream frame may contain.
+func maxStreamChunkSize() int {
+	if MaxMessageSize > 0 && MaxMessageSize < StreamChunkSize {
+		return MaxMessageSize
+	}
+	return StreamChunkSize
+}
+
+// EncryptStream encrypts everything read from src for the peer public key and writes the result to dst, in the
+// same format as NewEncryptWriter. Rather than buffering full chunks, every read of up to chunkSize bytes from src
+// is sealed and written as a frame right away, so data is passed on as soon as it arrives. A chunkSize of 0, or
+// one larger than StreamChunkSize, uses StreamChunkSize.
+//
+// As with NewEncryptWriter the nonce of each frame ends with its sequence number, which the decrypt reader checks,
+// so frames can't be dropped or reordered.
+func (key PrivateKey) EncryptStream(peer PublicKey, src io.Reader, dst io.Writer, chunkSize int) error {
+	if chunkSize < 0 {
+		return fmt.Errorf("invalid chunk size %d", chunkSize)
+	}
+	if chunkSize == 0 || chunkSize > maxStreamChunkSize() {
+		chunkSize = maxStreamChunkSize()
+	}
+
+	ew := key.newEncryptWriter(peer, dst)
+	if ew.err != nil {
+		return ew.err
+	}
+
+	buf := make([]byte, chunkSize)
+	for {
+		n, err := src.Read(buf)
+		if n > 0 {
+			ew.buf = buf[:n]
+			if err := ew.writeFrame(streamFlagData); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+	}
+	return ew.Close()
+}
+
+type encryptReader struct {
+	src  io.Reader
+	ew   *encryptWriter
+	out  bytes.Buffer
+	done bool
+	err  error
+}
+
+// EncryptReader returns a reader of the encrypted plaintext, in the same format as NewEncryptWriter. The plaintext
+// is only read and sealed as the returned reader is read, one chunk of up to StreamChunkSize bytes at a time, so
+// the ciphertext is never buffered in full. Errors from plaintext are returned by Read.
+func (key PrivateKey) EncryptReader(peer PublicKey, plaintext io.Reader) io.Reader {
+	er := &encryptReader{src: plaintext}
+	er.ew = key.newEncryptWriter(peer, &er.out)
+	return er
+}
+
+func (er *encryptReader) Read(p []byte) (int, error) {
+	for er.out.Len() == 0 {
+		if er.err != nil {
+			return 0, er.err
+		}
+		if er.done {
+			return 0, io.EOF
+		}
+		er.err = er.sealChunk()
+	}
+	return er.out.Read(p)
+}
+
+// sealChunk reads the next chunk of plaintext and seals it into the output buffer.
+func (er *encryptReader) sealChunk() error {
+	n, err := io.ReadFull(er.src, er.ew.buf[:er.ew.chunkSize])
+	er.ew.buf = er.ew.buf[:n]
+	switch err {
+	case nil:
+		return er.ew.writeFrame(streamFlagData)
+	case io.EOF, io.ErrUnexpectedEOF:
+		// the last chunk is sealed along with the end-of-stream marker
+		er.done = true
+		return er.ew.Close()
+	default:
+		return err
+	}
+}
+
+func (ew *encryptWriter) Write(p []byte) (int, error) {
+	if ew.err != nil {
+		return 0, ew.err
+	}
+	if ew.closed {
+		return 0, errors.New("write to closed encrypt writer")
+	}
+
+	n := 0
+	for len(p) > 0 {
+		if len(ew.buf) == ew.chunkSize {
+			if err := ew.writeFrame(streamFlagData); err != nil {
+				return n, err
+			}
+		}
+
+		c := copy(ew.buf[len(ew.buf):ew.chunkSize], p)
+		ew.buf = ew.buf[:len(ew.buf)+c]
+		p = p[c:]
+		n += c
+	}
+	return n, nil
+}
+
+// Close flushes the final chunk and writes the end-of-stream marker.
+func (ew *encryptWriter) Close() error {
+	if ew.err != nil {
+		return ew.err
+	}
+	if ew.closed {
+		return nil
+	}
+	ew.closed = true
+	return ew.writeFrame(streamFlagFinal)
+}
+
+func (ew *encryptWriter) writeFrame(flag byte) error {
+	if ew.err != nil {
+		return ew.err
+	}
+	if !ew.wroteKey {
+		if _, err := ew.w.Write(ew.sender[:]); err != nil {
+			ew.err = err
+			return err
+		}
+		ew.wroteKey = true
+	}
+
+	nonce, err := ew.nonces.New()
+	if err != nil {
+		ew.err = err
+		return err
+	}
+
+	message := make([]byte, 0, 1+len(ew.buf))
+	message = append(message, flag)
+	message = append(message, ew.buf...)
+
+	frame := make([]byte, streamFrameLengthSize, streamFrameLengthSize+NonceSize+len(message)+box.Overhead)
+	frame = append(frame, nonce[:]...)
+	frame = box.SealAfterPrecomputation(frame, message, nonce.ptr(), &ew.sharedKey)
+	binary.BigEndian.PutUint32(frame, uint32(len(frame)-streamFrameLengthSize))
+
+	if _, err := ew.w.Write(frame); err != nil {
+		ew.err = err
+		return err
+	}
+	ew.buf = ew.buf[:0]
+	return nil
+}
+
+type decryptReader struct {
+	r         io.Reader
+	sharedKey [KeySize]byte
+	frame     []byte
+	plaintext []byte
+	buf       []byte
+	prefix    [sessionNoncePrefixSize]byte
+	counter   uint64
+	done      bool
+	err       error
+}
+
+// NewDecryptReader returns a reader which decrypts a stream produced by NewEncryptWriter. The sender's public key is
+// read from r immediately and returned.
+//
+// Each frame is authenticated before any of its plaintext is returned. The nonce of every frame must continue the
+// sequence started by the first frame, otherwise Read returns ErrChunkOrder. If r ends before the end-of-stream
+// marker is read, Read returns io.ErrUnexpectedEOF so truncated streams are never mistaken for complete ones.
+func (key PrivateKey) NewDecryptReader(r io.Reader) (PublicKey, io.Reader, error) {
+	var sender PublicKey
+	if _, err := io.ReadFull(r, sender[:]); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return sender, nil, decryptFailed(fmt.Errorf("invalid stream: expected public key: %w", err))
+	}
+	if err := sender.Validate(); err != nil {
+		return sender, nil, decryptFailed(fmt.Errorf("invalid stream: %w", err))
+	}
+
+	var priv [KeySize]byte
+	copy(priv[:], key[:KeySize])
+
+	var pub [KeySize]byte
+	copy(pub[:], sender[:])
+
+	dr := &decryptReader{
+		r:         r,
+		frame:     make([]byte, maxStreamFrameSize),
+		plaintext: make([]byte, 0, 1+StreamChunkSize),
+	}
+	box.Precompute(&dr.sharedKey, &pub, &priv)
+	return sender, dr, nil
+}
+
+func (dr *decryptReader) Read(p []byte) (int, error) {
+	for len(dr.buf) == 0 {
+		if dr.err != nil {
+			return 0, dr.err
+		}
+		if dr.done {
+			return 0, io.EOF
+		}
+		if err := dr.readFrame(); err != nil {
+			dr.err = decryptFailed(err)
+		}
+	}
+
+	n := copy(p, dr.buf)
+	dr.buf = dr.buf[n:]
+	return n, nil
+}
+
+func (dr *decryptReader) readFrame() error {
+	var header [streamFrameLengthSize]byte
+	if _, err := io.ReadFull(dr.r, header[:]); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return fmt.Errorf("invalid stream: expected frame: %w", err)
+	}
+
+	n := binary.BigEndian.Uint32(header[:])
+	if n < NonceSize+1+box.Overhead || n > maxStreamFrameSize {
+		return fmt.Errorf("invalid stream: invalid frame length %d", n)
+	}
+	if int(n)-NonceSize-1-box.Overhead > MaxMessageSize {
+		return fmt.Errorf("invalid stream: %w", ErrMessageTooLarge)
+	}
+
+	frame := dr.frame[:n]
+	if _, err := io.ReadFull(dr.r, frame); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return fmt.Errorf("invalid stream: expected frame: %w", err)
+	}
+
+	// the first frame sets the nonce prefix of the stream, every nonce after that is recomputed from the prefix and
+	// the frame counter rather than taken from the wire
+	if dr.counter == 0 {
+		copy(dr.prefix[:], frame)
+	}
+	nonce := NonceForSequence(dr.prefix, dr.counter)
+	if !bytes.Equal(nonce[:], frame[:NonceSize]) {
+		return fmt.Errorf("invalid stream: frame %d: %w", dr.counter, ErrChunkOrder)
+	}
+	dr.counter++
+
+	opened, ok := box.OpenAfterPrecomputation(dr.plaintext[:0], frame[NonceSize:], nonce.ptr(), &dr.sharedKey)
+	if !ok {
+		return fmt.Errorf("invalid stream: %w", ErrOpenFailed)
+	}
+
+	switch opened[0] {
+	case streamFlagData:
+	case streamFlagFinal:
+		dr.done = true
+	default:
+		return fmt.Errorf("invalid stream: unknown frame flag %d", opened[0])
+	}
+	dr.buf = opened[1:]
+	return nil
+}
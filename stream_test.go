@@ -0,0 +1,338 @@
+package crypt
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func encryptStream(t *testing.T, sender PrivateKey, peer PublicKey, msg []byte) []byte {
+	var buf bytes.Buffer
+	w := sender.NewEncryptWriter(peer, &buf)
+	// write in odd sized pieces so chunks don't line up with writes
+	for rest := msg; len(rest) > 0; {
+		n := 10007
+		if n > len(rest) {
+			n = len(rest)
+		}
+		_, err := w.Write(rest[:n])
+		assert.NoError(t, err)
+		rest = rest[n:]
+	}
+	assert.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestStream(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	msg := make([]byte, 5*1024*1024+123)
+	_, err = io.ReadFull(rand.Reader, msg)
+	assert.NoError(t, err)
+
+	stream := encryptStream(t, k1, k2.PublicKey(), msg)
+
+	sender, r, err := k2.NewDecryptReader(bytes.NewReader(stream))
+	if assert.NoError(t, err) {
+		assert.Equal(t, k1.PublicKey(), sender)
+		decrypted, err := ioutil.ReadAll(r)
+		assert.NoError(t, err)
+		assert.True(t, bytes.Equal(msg, decrypted))
+	}
+}
+
+func TestStreamEmpty(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	stream := encryptStream(t, k1, k2.PublicKey(), nil)
+
+	_, r, err := k2.NewDecryptReader(bytes.NewReader(stream))
+	if assert.NoError(t, err) {
+		decrypted, err := ioutil.ReadAll(r)
+		assert.NoError(t, err)
+		assert.Empty(t, decrypted)
+	}
+}
+
+func TestEncryptWriterClosed(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+
+	w := k1.NewEncryptWriter(k1.PublicKey(), ioutil.Discard)
+	assert.NoError(t, w.Close())
+	_, err = w.Write([]byte("more"))
+	assert.Error(t, err)
+}
+
+func TestDecryptReaderCorrupted(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	msg := make([]byte, StreamChunkSize*3)
+	stream := encryptStream(t, k1, k2.PublicKey(), msg)
+
+	// flip a byte in the second frame
+	frameSize := streamFrameLengthSize + int(binary.BigEndian.Uint32(stream[KeySize:]))
+	stream[KeySize+frameSize+streamFrameLengthSize+NonceSize+10] ^= 0xff
+
+	_, r, err := k2.NewDecryptReader(bytes.NewReader(stream))
+	if assert.NoError(t, err) {
+		decrypted, err := ioutil.ReadAll(r)
+		assert.Error(t, err)
+		assert.Len(t, decrypted, StreamChunkSize, "only the first chunk should be returned")
+	}
+}
+
+func TestDecryptReaderTruncated(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	msg := make([]byte, StreamChunkSize*2+1)
+	stream := encryptStream(t, k1, k2.PublicKey(), msg)
+
+	// drop the final frame
+	frameSize := streamFrameLengthSize + int(binary.BigEndian.Uint32(stream[KeySize:]))
+	stream = stream[:KeySize+frameSize*2]
+
+	_, r, err := k2.NewDecryptReader(bytes.NewReader(stream))
+	if assert.NoError(t, err) {
+		_, err := ioutil.ReadAll(r)
+		assert.True(t, errors.Is(err, io.ErrUnexpectedEOF))
+	}
+}
+
+// splitStream splits a stream into the sender's public key and its frames.
+func splitStream(t *testing.T, stream []byte) ([]byte, [][]byte) {
+	var frames [][]byte
+	for rest := stream[KeySize:]; len(rest) > 0; {
+		n := streamFrameLengthSize + int(binary.BigEndian.Uint32(rest))
+		if !assert.True(t, n <= len(rest)) {
+			break
+		}
+		frames = append(frames, rest[:n])
+		rest = rest[n:]
+	}
+	return stream[:KeySize], frames
+}
+
+func TestDecryptReaderFrameOrder(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	msg := make([]byte, StreamChunkSize*3+1)
+	_, err = io.ReadFull(rand.Reader, msg)
+	assert.NoError(t, err)
+
+	key, frames := splitStream(t, encryptStream(t, k1, k2.PublicKey(), msg))
+	assert.Len(t, frames, 4, "3 data frames and the final frame")
+	_, other := splitStream(t, encryptStream(t, k1, k2.PublicKey(), msg))
+
+	for _, tc := range []struct {
+		name   string
+		frames [][]byte
+	}{
+		{"DropFirst", [][]byte{frames[1], frames[2], frames[3]}},
+		{"DropMiddle", [][]byte{frames[0], frames[2], frames[3]}},
+		{"Reorder", [][]byte{frames[0], frames[2], frames[1], frames[3]}},
+		{"Duplicate", [][]byte{frames[0], frames[1], frames[1], frames[2], frames[3]}},
+		{"Splice", [][]byte{frames[0], other[1], frames[2], frames[3]}},
+		{"SpliceFinal", [][]byte{frames[0], frames[1], frames[2], other[3]}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			stream := append([]byte(nil), key...)
+			stream = append(stream, bytes.Join(tc.frames, nil)...)
+
+			_, r, err := k2.NewDecryptReader(bytes.NewReader(stream))
+			if assert.NoError(t, err) {
+				_, err := ioutil.ReadAll(r)
+				assert.True(t, errors.Is(err, ErrChunkOrder), "%v", err)
+			}
+		})
+	}
+}
+
+func TestStreamMaxMessageSize(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	defer func(size int) { MaxMessageSize = size }(MaxMessageSize)
+	MaxMessageSize = 1000
+
+	msg := make([]byte, 2500)
+	_, err = io.ReadFull(rand.Reader, msg)
+	assert.NoError(t, err)
+	stream := encryptStream(t, k1, k2.PublicKey(), msg)
+
+	// the limit applies per chunk
+	frameSize := int(binary.BigEndian.Uint32(stream[KeySize:]))
+	assert.Equal(t, NonceSize+1+MaxMessageSize+16, frameSize)
+
+	_, r, err := k2.NewDecryptReader(bytes.NewReader(stream))
+	if assert.NoError(t, err) {
+		decrypted, err := ioutil.ReadAll(r)
+		assert.NoError(t, err)
+		assert.Equal(t, msg, decrypted)
+	}
+
+	MaxMessageSize = 999
+	_, r, err = k2.NewDecryptReader(bytes.NewReader(stream))
+	if assert.NoError(t, err) {
+		_, err := ioutil.ReadAll(r)
+		assert.True(t, errors.Is(err, ErrMessageTooLarge))
+	}
+}
+
+func TestEncryptStream(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	msg := make([]byte, 10000)
+	_, err = io.ReadFull(rand.Reader, msg)
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, k1.EncryptStream(k2.PublicKey(), iotest.HalfReader(bytes.NewReader(msg)), &buf, 1000))
+	stream := buf.Bytes()
+
+	sender, r, err := k2.NewDecryptReader(bytes.NewReader(stream))
+	if assert.NoError(t, err) {
+		assert.Equal(t, k1.PublicKey(), sender)
+		decrypted, err := ioutil.ReadAll(r)
+		assert.NoError(t, err)
+		assert.Equal(t, msg, decrypted)
+	}
+
+	// every short read is sealed as a frame right away, numbered by its nonce
+	var prefix []byte
+	frames := 0
+	for rest := stream[KeySize:]; len(rest) > 0; frames++ {
+		n := int(binary.BigEndian.Uint32(rest))
+		frame := rest[streamFrameLengthSize : streamFrameLengthSize+n]
+		if frames < 20 {
+			assert.Equal(t, NonceSize+1+500+16, n)
+		}
+		if prefix == nil {
+			prefix = frame[:NonceSize-8]
+		}
+		assert.Equal(t, prefix, frame[:NonceSize-8])
+		assert.Equal(t, uint64(frames), binary.BigEndian.Uint64(frame[NonceSize-8:NonceSize]))
+		rest = rest[streamFrameLengthSize+n:]
+	}
+	assert.Equal(t, 21, frames, "20 data frames and the final frame")
+
+	t.Run("DefaultChunkSize", func(t *testing.T) {
+		msg := make([]byte, StreamChunkSize*2+1)
+		var buf bytes.Buffer
+		assert.NoError(t, k1.EncryptStream(k2.PublicKey(), bytes.NewReader(msg), &buf, 0))
+		assert.Equal(t, NonceSize+1+StreamChunkSize+16, int(binary.BigEndian.Uint32(buf.Bytes()[KeySize:])))
+
+		_, r, err := k2.NewDecryptReader(&buf)
+		if assert.NoError(t, err) {
+			decrypted, err := ioutil.ReadAll(r)
+			assert.NoError(t, err)
+			assert.Equal(t, msg, decrypted)
+		}
+	})
+	t.Run("ReadError", func(t *testing.T) {
+		r := io.MultiReader(bytes.NewReader(msg), errReader{errors.New("read failed")})
+		err := k1.EncryptStream(k2.PublicKey(), r, ioutil.Discard, 0)
+		assert.EqualError(t, err, "read failed")
+	})
+	t.Run("FrameOrder", func(t *testing.T) {
+		key, frames := splitStream(t, stream)
+		for _, reordered := range [][][]byte{
+			append([][]byte{frames[0], frames[2], frames[1]}, frames[3:]...),
+			append([][]byte{frames[0]}, frames[2:]...),
+		} {
+			_, r, err := k2.NewDecryptReader(bytes.NewReader(append(append([]byte(nil), key...), bytes.Join(reordered, nil)...)))
+			if assert.NoError(t, err) {
+				_, err := ioutil.ReadAll(r)
+				assert.True(t, errors.Is(err, ErrChunkOrder), "%v", err)
+			}
+		}
+	})
+}
+
+func TestEncryptReader(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	for _, size := range []int{0, 1, StreamChunkSize, StreamChunkSize*3 + 7} {
+		msg := make([]byte, size)
+		_, err = io.ReadFull(rand.Reader, msg)
+		assert.NoError(t, err)
+
+		// pipe the output straight into the decrypt reader
+		r := k1.EncryptReader(k2.PublicKey(), iotest.HalfReader(bytes.NewReader(msg)))
+		sender, dr, err := k2.NewDecryptReader(iotest.OneByteReader(r))
+		if assert.NoError(t, err, size) {
+			assert.Equal(t, k1.PublicKey(), sender)
+			decrypted, err := ioutil.ReadAll(dr)
+			assert.NoError(t, err, size)
+			assert.True(t, bytes.Equal(msg, decrypted), size)
+		}
+	}
+
+	t.Run("Lazy", func(t *testing.T) {
+		src := &countingReader{r: bytes.NewReader(make([]byte, StreamChunkSize*10))}
+		r := k1.EncryptReader(k2.PublicKey(), src)
+		assert.Zero(t, src.n)
+
+		_, err := io.ReadFull(r, make([]byte, KeySize))
+		assert.NoError(t, err)
+		assert.Equal(t, StreamChunkSize, src.n, "only the first chunk should be read")
+	})
+	t.Run("FrameOrder", func(t *testing.T) {
+		stream, err := ioutil.ReadAll(k1.EncryptReader(k2.PublicKey(), bytes.NewReader(make([]byte, StreamChunkSize*3))))
+		assert.NoError(t, err)
+		key, frames := splitStream(t, stream)
+		reordered := append([][]byte{frames[0], frames[2], frames[1]}, frames[3:]...)
+
+		_, r, err := k2.NewDecryptReader(bytes.NewReader(append(append([]byte(nil), key...), bytes.Join(reordered, nil)...)))
+		if assert.NoError(t, err) {
+			_, err := ioutil.ReadAll(r)
+			assert.True(t, errors.Is(err, ErrChunkOrder), "%v", err)
+		}
+	})
+	t.Run("ReadError", func(t *testing.T) {
+		r := io.MultiReader(bytes.NewReader(make([]byte, 10)), errReader{errors.New("read failed")})
+		_, err := ioutil.ReadAll(k1.EncryptReader(k2.PublicKey(), r))
+		assert.EqualError(t, err, "read failed")
+	})
+}
+
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += n
+	return n, err
+}
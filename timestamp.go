@@ -0,0 +1,64 @@
+package crypt
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/nacl/box"
+)
+
+// ErrMessageExpired indicates that a timestamped message is older than the maximum age or dated in the future.
+var ErrMessageExpired = errors.New("message expired")
+
+const (
+	timestampSize = 8
+	// maxClockSkew is how far in the future a timestamped message may be dated to allow for clocks being out of sync.
+	maxClockSkew = time.Minute
+)
+
+// Now returns the current time for timestamped messages and KeyInfo. It defaults to time.Now and is intended to be
+// replaced in tests only, to freeze or advance time. Reading Now concurrently is safe, but it must not be replaced
+// concurrently with other functions of the package.
+var Now = time.Now
+
+// EncryptTimestamped is like Encrypt, but seals the current time along with the data so the peer can reject stale
+// messages via DecryptTimestamped. The message is marked as timestamped in its header.
+func (key PrivateKey) EncryptTimestamped(peersPublicKey PublicKey, data []byte) []byte {
+	message := timestamp(Now(), data)
+	result := make([]byte, 0, HeaderSize+KeySize+NonceSize+len(message)+box.Overhead)
+	return key.encryptAppend(result, peersPublicKey, mustGenerateNonce(), MessageTypeTimestamped, message)
+}
+
+// timestamp returns the data prefixed with the time it was sent, as sealed in a timestamped message.
+func timestamp(sent time.Time, data []byte) []byte {
+	message := make([]byte, timestampSize, timestampSize+len(data))
+	binary.BigEndian.PutUint64(message, uint64(sent.UnixNano()))
+	return append(message, data...)
+}
+
+// DecryptTimestamped decrypts data that was encrypted via EncryptTimestamped. ErrMessageExpired is returned if the
+// message is older than maxAge or dated more than a minute in the future. This offers basic replay resistance
+// without keeping state, a message can still be replayed within maxAge.
+func (key PrivateKey) DecryptTimestamped(data []byte, maxAge time.Duration) (PublicKey, []byte, error) {
+	typ, body, ok := parseHeader(data)
+	if !ok || typ != MessageTypeTimestamped {
+		return PublicKey{}, nil, decryptFailed(errors.New("invalid message: not timestamped"))
+	}
+
+	pub, message, err := key.decryptBox(nil, MessageTypeTimestamped, body)
+	if err != nil {
+		return pub, nil, decryptFailed(err)
+	}
+	if len(message) < timestampSize {
+		return pub, nil, decryptFailed(errors.New("invalid message: expected timestamp"))
+	}
+
+	sent := time.Unix(0, int64(binary.BigEndian.Uint64(message)))
+	current := Now()
+	if current.Sub(sent) > maxAge || sent.Sub(current) > maxClockSkew {
+		return pub, nil, decryptFailed(fmt.Errorf("invalid message: %w: sent at %s", ErrMessageExpired, sent.UTC().Format(time.RFC3339)))
+	}
+	return pub, message[timestampSize:], nil
+}
@@ -0,0 +1,92 @@
+package crypt
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncryptTimestamped(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	defer func() { Now = time.Now }()
+	current := time.Date(2020, 7, 1, 12, 0, 0, 0, time.UTC)
+	Now = func() time.Time { return current }
+
+	msg := []byte("Hello World")
+	encrypted := k1.EncryptTimestamped(k2.PublicKey(), msg)
+	assert.Equal(t, MessageTypeTimestamped, encrypted[HeaderSize-1])
+
+	pub, decrypted, err := k2.DecryptTimestamped(encrypted, time.Minute)
+	if assert.NoError(t, err) {
+		assert.Equal(t, k1.PublicKey(), pub)
+		assert.Equal(t, msg, decrypted)
+	}
+
+	current = current.Add(time.Minute)
+	_, _, err = k2.DecryptTimestamped(encrypted, time.Minute)
+	assert.NoError(t, err, "a message exactly maxAge old should be accepted")
+
+	current = current.Add(time.Nanosecond)
+	_, _, err = k2.DecryptTimestamped(encrypted, time.Minute)
+	assert.True(t, errors.Is(err, ErrMessageExpired))
+	assert.EqualError(t, err, "invalid message: message expired: sent at 2020-07-01T12:00:00Z")
+
+	// messages dated in the future are rejected beyond the allowed clock skew
+	current = time.Date(2020, 7, 1, 11, 59, 0, 0, time.UTC)
+	_, _, err = k2.DecryptTimestamped(encrypted, time.Hour)
+	assert.NoError(t, err)
+	current = current.Add(-time.Nanosecond)
+	_, _, err = k2.DecryptTimestamped(encrypted, time.Hour)
+	assert.True(t, errors.Is(err, ErrMessageExpired))
+
+	_, _, err = k2.Decrypt(encrypted)
+	assert.Error(t, err, "Decrypt should not skip the age check")
+	retyped := append([]byte(nil), encrypted...)
+	retyped[HeaderSize-1] = MessageTypeBox
+	_, _, err = k2.Decrypt(retyped)
+	assert.True(t, errors.Is(err, ErrOpenFailed), "changing the type should not skip the age check either")
+	_, _, err = k2.Decrypt(encrypted[HeaderSize:])
+	assert.True(t, errors.Is(err, ErrOpenFailed), "nor should stripping the header")
+	_, _, err = k2.DecryptTimestamped(k1.Encrypt(k2.PublicKey(), msg), time.Hour)
+	assert.EqualError(t, err, "invalid message: not timestamped")
+
+	sender, err := DecryptPeekSender(encrypted)
+	if assert.NoError(t, err) {
+		assert.Equal(t, k1.PublicKey(), sender)
+	}
+}
+
+func TestNow(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	defer func() { Now = time.Now }()
+	current := time.Unix(1600000000, 0)
+	Now = func() time.Time { return current }
+
+	encrypted := k1.EncryptTimestamped(k2.PublicKey(), []byte("Hello World"))
+
+	// advancing the clock rather than sleeping makes the expiry deterministic
+	for _, tc := range []struct {
+		age     time.Duration
+		expired bool
+	}{
+		{0, false},
+		{30 * time.Second, false},
+		{time.Hour, false},
+		{time.Hour + time.Second, true},
+		{24 * time.Hour, true},
+	} {
+		current = time.Unix(1600000000, 0).Add(tc.age)
+		_, _, err := k2.DecryptTimestamped(encrypted, time.Hour)
+		assert.Equal(t, tc.expired, errors.Is(err, ErrMessageExpired), "age %s", tc.age)
+	}
+}
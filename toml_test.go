@@ -0,0 +1,46 @@
+package crypt
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTOML(t *testing.T) {
+	priv, err := Generate()
+	assert.NoError(t, err)
+	pub := priv.PublicKey()
+
+	type config struct {
+		Private PrivateKey
+		Public  PublicKey
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, toml.NewEncoder(&buf).Encode(config{Private: priv, Public: pub}))
+	assert.Equal(t, "Private = \""+priv.String()+"\"\nPublic = \""+pub.String()+"\"\n", buf.String())
+
+	var decoded config
+	_, err = toml.Decode(buf.String(), &decoded)
+	if assert.NoError(t, err) {
+		assert.Equal(t, priv, decoded.Private)
+		assert.Equal(t, pub, decoded.Public)
+	}
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, doc := range []string{
+			`Public = "abc"`,
+			`Public = "not base58: 0OIl"`,
+			`Private = "` + pub.String() + `1"`,
+		} {
+			var decoded config
+			_, err := toml.Decode(doc, &decoded)
+			if assert.Error(t, err, doc) {
+				assert.True(t, strings.Contains(err.Error(), "invalid key"), err.Error())
+			}
+		}
+	})
+}
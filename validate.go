@@ -0,0 +1,68 @@
+package crypt
+
+import (
+	"crypto/subtle"
+	"errors"
+
+	"golang.org/x/crypto/curve25519"
+)
+
+// ErrLowOrderPoint indicates that a public key is one of the low-order points of Curve25519. The shared secret
+// computed with such a key is predictable.
+var ErrLowOrderPoint = errors.New("invalid key: low order point")
+
+// lowOrderPoints are the encodings of the points of small order on Curve25519, including non-canonical encodings.
+// The most significant bit is ignored by X25519 and must be cleared before comparing.
+var lowOrderPoints = [][KeySize]byte{
+	// 0 (order 4)
+	{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+	// 1 (order 1)
+	{0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+	// 325606250916557431795983626356110631294008115727848805560023387167927233504 (order 8)
+	{0xe0, 0xeb, 0x7a, 0x7c, 0x3b, 0x41, 0xb8, 0xae, 0x16, 0x56, 0xe3, 0xfa, 0xf1, 0x9f, 0xc4, 0x6a,
+		0xda, 0x09, 0x8d, 0xeb, 0x9c, 0x32, 0xb1, 0xfd, 0x86, 0x62, 0x05, 0x16, 0x5f, 0x49, 0xb8, 0x00},
+	// 39382357235489614581723060781553021112529911719440698176882885853963445705823 (order 8)
+	{0x5f, 0x9c, 0x95, 0xbc, 0xa3, 0x50, 0x8c, 0x24, 0xb1, 0xd0, 0xb1, 0x55, 0x9c, 0x83, 0xef, 0x5b,
+		0x04, 0x44, 0x5c, 0xc4, 0x58, 0x1c, 0x8e, 0x86, 0xd8, 0x22, 0x4e, 0xdd, 0xd0, 0x9f, 0x11, 0x57},
+	// p-1 (order 2)
+	{0xec, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f},
+	// p (non-canonical 0)
+	{0xed, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f},
+	// p+1 (non-canonical 1)
+	{0xee, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f},
+}
+
+// Validate returns ErrLowOrderPoint if the public key is the all-zero key or another low-order point. The check is
+// constant time.
+func (key PublicKey) Validate() error {
+	k := key
+	k[KeySize-1] &= 0x7f
+
+	found := 0
+	for _, p := range lowOrderPoints {
+		found |= subtle.ConstantTimeCompare(k[:], p[:])
+	}
+	if found != 0 {
+		return ErrLowOrderPoint
+	}
+	return nil
+}
+
+// CheckKeyPair returns ErrKeyMismatch if the public key doesn't belong to the private key. The public key is
+// recomputed from the private scalar and compared in constant time, the public half stored in the private key is not
+// trusted.
+func CheckKeyPair(priv PrivateKey, pub PublicKey) error {
+	computed, err := curve25519.X25519(priv[:KeySize], curve25519.Basepoint)
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare(computed, pub[:]) != 1 {
+		return ErrKeyMismatch
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package crypt
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/curve25519"
+)
+
+func TestValidate(t *testing.T) {
+	lowOrder := []string{
+		"0000000000000000000000000000000000000000000000000000000000000000",
+		"0100000000000000000000000000000000000000000000000000000000000000",
+		"e0eb7a7c3b41b8ae1656e3faf19fc46ada098deb9c32b1fd866205165f49b800",
+		"5f9c95bca3508c24b1d0b1559c83ef5b04445cc4581c8e86d8224eddd09f1157",
+		"ecffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f",
+		"edffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f",
+		"eeffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f",
+		// the most significant bit is ignored
+		"0000000000000000000000000000000000000000000000000000000000000080",
+	}
+	k, err := Generate()
+	assert.NoError(t, err)
+
+	for _, str := range lowOrder {
+		pub, err := NewPublicKeyFromHex(str)
+		assert.NoError(t, err)
+		assert.Equal(t, ErrLowOrderPoint, pub.Validate(), str)
+
+		// the shared secret with a low order point is all zeros
+		_, err = curve25519.X25519(k[:KeySize], pub[:])
+		assert.Error(t, err, str)
+	}
+
+	assert.NoError(t, k.PublicKey().Validate())
+}
+
+func TestDecryptLowOrderPoint(t *testing.T) {
+	k, err := Generate()
+	assert.NoError(t, err)
+
+	encrypted := k.Encrypt(k.PublicKey(), []byte("Hello World"))
+	zero, _ := hex.DecodeString("e0eb7a7c3b41b8ae1656e3faf19fc46ada098deb9c32b1fd866205165f49b800")
+	copy(encrypted[HeaderSize:], zero)
+
+	_, _, err = k.Decrypt(encrypted)
+	assert.True(t, errors.Is(err, ErrLowOrderPoint))
+
+	// the legacy headerless form is checked as well
+	_, _, err = k.Decrypt(encrypted[HeaderSize:])
+	assert.True(t, errors.Is(err, ErrLowOrderPoint))
+
+	t.Run("Multi", func(t *testing.T) {
+		encrypted, err := k.EncryptMulti([]PublicKey{k.PublicKey()}, []byte("Hello World"))
+		assert.NoError(t, err)
+		copy(encrypted, zero)
+
+		_, _, err = k.DecryptMulti(encrypted)
+		assert.True(t, errors.Is(err, ErrLowOrderPoint))
+	})
+	t.Run("Stream", func(t *testing.T) {
+		var buf bytes.Buffer
+		w := k.NewEncryptWriter(k.PublicKey(), &buf)
+		_, err := w.Write([]byte("Hello World"))
+		assert.NoError(t, err)
+		assert.NoError(t, w.Close())
+		encrypted := buf.Bytes()
+		copy(encrypted, zero)
+
+		_, _, err = k.NewDecryptReader(bytes.NewReader(encrypted))
+		assert.True(t, errors.Is(err, ErrLowOrderPoint))
+	})
+	t.Run("File", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NoError(t, k.EncryptFile(k.PublicKey(), &buf, strings.NewReader("Hello World")))
+		encrypted := buf.Bytes()
+		copy(encrypted, zero)
+
+		var out bytes.Buffer
+		_, err := k.DecryptFile(&out, bytes.NewReader(encrypted))
+		assert.True(t, errors.Is(err, ErrLowOrderPoint))
+		assert.Zero(t, out.Len())
+	})
+}
+
+func TestCheckKeyPair(t *testing.T) {
+	k1, err := Generate()
+	assert.NoError(t, err)
+	k2, err := Generate()
+	assert.NoError(t, err)
+
+	assert.NoError(t, CheckKeyPair(k1, k1.PublicKey()))
+	assert.Equal(t, ErrKeyMismatch, CheckKeyPair(k1, k2.PublicKey()))
+
+	// the embedded public half isn't trusted
+	inconsistent := k1
+	copy(inconsistent[KeySize:], k2[KeySize:])
+	assert.Equal(t, ErrKeyMismatch, CheckKeyPair(inconsistent, inconsistent.PublicKey()))
+}
@@ -0,0 +1,126 @@
+package crypt
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// TestVector is a deterministic encryption test vector. Every field but Type is hex encoded so the vectors can be
+// consumed by other implementations of the message format.
+type TestVector struct {
+	Name string `json:"name"`
+	// Type is the message type declared in the header.
+	Type byte `json:"type"`
+	// SenderPrivateKey and RecipientPrivateKey are the 32 byte private scalars.
+	SenderPrivateKey    string `json:"sender_private_key"`
+	SenderPublicKey     string `json:"sender_public_key"`
+	RecipientPrivateKey string `json:"recipient_private_key"`
+	RecipientPublicKey  string `json:"recipient_public_key"`
+	// SharedKey is the precomputed key shared by the sender and the recipient.
+	SharedKey string `json:"shared_key"`
+	// MessageKey is the key the box is sealed with: the shared key for MessageTypeBox and the key derived from it
+	// for the type otherwise.
+	MessageKey string `json:"message_key"`
+	Nonce      string `json:"nonce"`
+	Plaintext  string `json:"plaintext"`
+	// Sealed is what the box seals: the plaintext itself for MessageTypeBox, otherwise the gzip compressed
+	// plaintext, the length prefixed plaintext padded to a multiple of testVectorBlockSize bytes or the plaintext
+	// prefixed with the timestamp. Other gzip implementations may compress differently, so compressed vectors are
+	// best checked by decrypting them.
+	Sealed string `json:"sealed"`
+	// Message is the complete message, including the header.
+	Message string `json:"message"`
+}
+
+const (
+	// testVectorBlockSize is the block size of the padded test vectors.
+	testVectorBlockSize = 64
+	// testVectorTimestamp is the time the timestamped test vectors were sent, in seconds since the Unix epoch.
+	testVectorTimestamp = 1600000000
+)
+
+// GenerateTestVectors returns test vectors for the message format, covering every message type. The keys, nonces,
+// plaintexts and timestamps are fixed, so the vectors are the same on every call and can be used to check other
+// implementations against this one.
+func GenerateTestVectors() ([]TestVector, error) {
+	plaintexts := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"hello", []byte("Hello World")},
+		{"block", sequentialBytes(0, 64)},
+		{"multiblock", sequentialBytes(0, 1000)},
+	}
+	types := []struct {
+		name   string
+		typ    byte
+		sealed func(data []byte) []byte
+	}{
+		{"box", MessageTypeBox, func(data []byte) []byte { return data }},
+		{"compressed", MessageTypeCompressed, compress},
+		{"padded", MessageTypePadded, func(data []byte) []byte { return pad(data, testVectorBlockSize) }},
+		{"timestamped", MessageTypeTimestamped, func(data []byte) []byte {
+			return timestamp(time.Unix(testVectorTimestamp, 0), data)
+		}},
+	}
+
+	var vectors []TestVector
+	for _, typ := range types {
+		for i, plaintext := range plaintexts {
+			sender, err := newPrivateKeyFromScalar(sequentialBytes(0x01+byte(i), KeySize))
+			if err != nil {
+				return nil, err
+			}
+			recipient, err := newPrivateKeyFromScalar(sequentialBytes(0x21+byte(i), KeySize))
+			if err != nil {
+				return nil, err
+			}
+			var nonce Nonce
+			copy(nonce[:], sequentialBytes(0x41+byte(i), NonceSize))
+
+			shared := sender.Precompute(recipient.PublicKey())
+			messageKey := messageKey(shared, typ.typ)
+			sealed := typ.sealed(plaintext.data)
+			message := sender.encryptAppend(nil, recipient.PublicKey(), nonce, typ.typ, sealed)
+			vectors = append(vectors, TestVector{
+				Name:                typ.name + "/" + plaintext.name,
+				Type:                typ.typ,
+				SenderPrivateKey:    hex.EncodeToString(sender[:KeySize]),
+				SenderPublicKey:     sender.PublicKey().HexString(),
+				RecipientPrivateKey: hex.EncodeToString(recipient[:KeySize]),
+				RecipientPublicKey:  recipient.PublicKey().HexString(),
+				SharedKey:           hex.EncodeToString(shared[:]),
+				MessageKey:          hex.EncodeToString(messageKey[:]),
+				Nonce:               hex.EncodeToString(nonce[:]),
+				Plaintext:           hex.EncodeToString(plaintext.data),
+				Sealed:              hex.EncodeToString(sealed),
+				Message:             hex.EncodeToString(message),
+			})
+		}
+	}
+	return vectors, nil
+}
+
+// MarshalTestVectors returns the test vectors as indented JSON.
+func MarshalTestVectors() ([]byte, error) {
+	vectors, err := GenerateTestVectors()
+	if err != nil {
+		return nil, err
+	}
+	bs, err := json.MarshalIndent(vectors, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(bs, '\n'), nil
+}
+
+// sequentialBytes returns n bytes counting up from start.
+func sequentialBytes(start byte, n int) []byte {
+	bs := make([]byte, n)
+	for i := range bs {
+		bs[i] = start + byte(i)
+	}
+	return bs
+}
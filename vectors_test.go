@@ -0,0 +1,83 @@
+package crypt
+
+import (
+	"encoding/hex"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/nacl/box"
+)
+
+var updateGolden = flag.Bool("update", false, "update the golden test vectors in testdata")
+
+func TestGenerateTestVectors(t *testing.T) {
+	golden := filepath.Join("testdata", "vectors.json")
+
+	generated, err := MarshalTestVectors()
+	assert.NoError(t, err)
+	if *updateGolden {
+		assert.NoError(t, ioutil.WriteFile(golden, generated, 0644))
+	}
+
+	expected, err := ioutil.ReadFile(golden)
+	assert.NoError(t, err)
+	assert.Equal(t, string(expected), string(generated), "run go test -update to regenerate %s", golden)
+
+	vectors, err := GenerateTestVectors()
+	assert.NoError(t, err)
+	types := map[byte]int{}
+	for _, v := range vectors {
+		types[v.Type]++
+		t.Run(v.Name, func(t *testing.T) {
+			recipient, err := NewPrivateKeyFromHex(v.RecipientPrivateKey)
+			assert.NoError(t, err)
+			assert.Equal(t, v.RecipientPublicKey, recipient.PublicKey().HexString())
+
+			message, err := hex.DecodeString(v.Message)
+			assert.NoError(t, err)
+			var sender PublicKey
+			var decrypted []byte
+			if v.Type == MessageTypeTimestamped {
+				sender, decrypted, err = recipient.DecryptTimestamped(message, time.Since(time.Unix(testVectorTimestamp, 0))+time.Hour)
+			} else {
+				sender, decrypted, err = recipient.Decrypt(message)
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, v.SenderPublicKey, sender.HexString())
+				assert.Equal(t, v.Plaintext, hex.EncodeToString(decrypted))
+			}
+
+			// the box opens with the message key alone
+			var key [KeySize]byte
+			bs, err := hex.DecodeString(v.MessageKey)
+			assert.NoError(t, err)
+			copy(key[:], bs)
+			var nonce [NonceSize]byte
+			bs, err = hex.DecodeString(v.Nonce)
+			assert.NoError(t, err)
+			copy(nonce[:], bs)
+			sealed, ok := box.OpenAfterPrecomputation(nil, message[HeaderSize+KeySize+NonceSize:], &nonce, &key)
+			if assert.True(t, ok) {
+				assert.Equal(t, v.Sealed, hex.EncodeToString(sealed))
+			}
+			if v.Type == MessageTypeBox {
+				assert.Equal(t, v.SharedKey, v.MessageKey)
+			} else {
+				assert.NotEqual(t, v.SharedKey, v.MessageKey)
+			}
+		})
+	}
+	assert.Equal(t, map[byte]int{
+		MessageTypeBox:         4,
+		MessageTypeCompressed:  4,
+		MessageTypePadded:      4,
+		MessageTypeTimestamped: 4,
+	}, types)
+
+	// the first vector uses the same keys and nonce as the libsodium vectors
+	assert.Equal(t, "07a37cbc142093c8b755dc1b10e86cb426374ad16aa853ed0bdfc0b2b86d1c7c", vectors[0].SenderPublicKey)
+}
@@ -0,0 +1,64 @@
+package crypt
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	yaml "gopkg.in/yaml.v3"
+)
+
+func TestYAMLv3(t *testing.T) {
+	kp, err := GenerateKeyPair()
+	assert.NoError(t, err)
+
+	type config struct {
+		Private  PrivateKey `yaml:"private"`
+		Public   PublicKey  `yaml:"public"`
+		Identity KeyPair    `yaml:"identity"`
+	}
+
+	bs, err := yaml.Marshal(config{Private: kp.Private, Public: kp.Public, Identity: kp})
+	assert.NoError(t, err)
+	assert.Equal(t, "private: "+kp.Private.String()+"\npublic: "+kp.Public.String()+"\nidentity: "+kp.Private.String()+"\n", string(bs))
+
+	var decoded config
+	if assert.NoError(t, yaml.Unmarshal(bs, &decoded)) {
+		assert.Equal(t, kp.Private, decoded.Private)
+		assert.Equal(t, kp.Public, decoded.Public)
+		assert.Equal(t, kp, decoded.Identity)
+	}
+
+	var invalid config
+	err = yaml.Unmarshal([]byte("public: abc\n"), &invalid)
+	assert.EqualError(t, err, "invalid key: got 3 bytes, want 32")
+	err = yaml.Unmarshal([]byte("private: [1, 2]\n"), &invalid)
+	assert.Error(t, err)
+}
+
+func TestYAMLPublicKeyAsPrivateKey(t *testing.T) {
+	kp, err := GenerateKeyPair()
+	assert.NoError(t, err)
+
+	type config struct {
+		Private  PrivateKey `yaml:"private"`
+		Identity KeyPair    `yaml:"identity"`
+	}
+
+	var decoded config
+	err = yaml.Unmarshal([]byte("private: "+kp.Public.String()+"\n"), &decoded)
+	assert.True(t, errors.Is(err, ErrInvalidKeyLength))
+	assert.EqualError(t, err, "invalid key: got 32 bytes, want 64: the value looks like a public key")
+	assert.True(t, decoded.Private.IsZero())
+
+	err = yaml.Unmarshal([]byte("identity: "+kp.Public.String()+"\n"), &decoded)
+	assert.True(t, errors.Is(err, ErrInvalidKeyLength))
+
+	// the 32 byte form is still accepted elsewhere
+	_, err = NewPrivateKey(kp.Public.String())
+	assert.NoError(t, err)
+	var priv PrivateKey
+	assert.NoError(t, priv.UnmarshalText([]byte(kp.Public.String())))
+	assert.NoError(t, json.Unmarshal([]byte(`"`+kp.Public.String()+`"`), &priv))
+}